@@ -1,10 +1,17 @@
 package ultrastar
 
 import (
+	"errors"
 	"math"
 	"time"
 )
 
+// These known errors might be returned by some of the functions and methods in this package.
+var (
+	// ErrInvalidBPM denotes that a BPM value is not valid as determined by [BPM.IsValid].
+	ErrInvalidBPM = errors.New("invalid BPM")
+)
+
 // BPM is a measurement of the 'speed' of a song.
 // It counts the number of Beat's per minute.
 type BPM float64
@@ -0,0 +1,83 @@
+package ultrastar
+
+import (
+	"errors"
+	"sort"
+)
+
+// These known errors might be returned by some of the functions and methods in this package.
+var (
+	// ErrInvalidPlayer denotes that a player number other than 1 or 2 was used.
+	ErrInvalidPlayer = errors.New("invalid player")
+)
+
+// A SongBuilder can be used to construct a [Song] programmatically.
+// In contrast to constructing a Song directly, a SongBuilder validates notes as they are added.
+// This way errors are detected at insertion time instead of when the song is used.
+//
+// The zero value of a SongBuilder is an empty song ready to use.
+type SongBuilder struct {
+	song Song
+}
+
+// SetBPM sets the BPM of the song.
+// The BPM is validated in [SongBuilder.Build].
+func (b *SongBuilder) SetBPM(bpm BPM) {
+	b.song.BPM = bpm
+}
+
+// SetTitle sets the title of the song.
+func (b *SongBuilder) SetTitle(title string) {
+	b.song.Title = title
+}
+
+// AddNote adds n to the notes of the specified player.
+// player must be 1 or 2, otherwise ErrInvalidPlayer is returned.
+// Adding a note for player 2 turns the song into a duet.
+//
+// Notes can be added in any order.
+// If n has a negative duration, ErrNegativeDuration is returned.
+// If n overlaps with a note of the same player, ErrOverlappingNotes is returned.
+// Line breaks never overlap with other notes.
+// If an error is returned, n is not added to the song.
+func (b *SongBuilder) AddNote(player int, n Note) error {
+	var ns *Notes
+	switch player {
+	case 1:
+		ns = &b.song.NotesP1
+	case 2:
+		ns = &b.song.NotesP2
+	default:
+		return ErrInvalidPlayer
+	}
+	if n.Duration < 0 {
+		return ErrNegativeDuration
+	}
+	for _, other := range *ns {
+		if n.Overlaps(other) {
+			return ErrOverlappingNotes
+		}
+	}
+	*ns = AddNote(*ns, n)
+	return nil
+}
+
+// Build returns the constructed song.
+// The notes of the song are sorted by their start beat.
+// If the BPM of the song is not valid, ErrInvalidBPM is returned together with the song.
+//
+// The returned song does not share any notes with b,
+// so b can continue to be used after Build has been called.
+func (b *SongBuilder) Build() (Song, error) {
+	s := b.song
+	s.NotesP1 = append(Notes(nil), b.song.NotesP1...)
+	if b.song.NotesP2 != nil {
+		s.NotesP2 = append(Notes{}, b.song.NotesP2...)
+	}
+	sort.Stable(s.NotesP1)
+	sort.Stable(s.NotesP2)
+	if !s.BPM.IsValid() {
+		return s, ErrInvalidBPM
+	}
+	return s, nil
+}
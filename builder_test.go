@@ -0,0 +1,65 @@
+package ultrastar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSongBuilder(t *testing.T) {
+	t.Run("valid song", func(t *testing.T) {
+		b := &SongBuilder{}
+		b.SetBPM(120)
+		b.SetTitle("All Star")
+		notes := []struct {
+			player int
+			note   Note
+		}{
+			{1, Note{NoteTypeRegular, 6, 2, 0, "body"}},
+			{1, Note{NoteTypeRegular, 0, 6, 0, "Some"}},
+			{1, Note{NoteTypeLineBreak, 8, 0, 0, "\n"}},
+			{2, Note{NoteTypeGolden, 8, 4, 3, "once"}},
+		}
+		for _, n := range notes {
+			if err := b.AddNote(n.player, n.note); err != nil {
+				t.Errorf("b.AddNote(%d, %v) caused an unexpected error: %s", n.player, n.note, err)
+			}
+		}
+		s, err := b.Build()
+		if err != nil {
+			t.Fatalf("b.Build() caused an unexpected error: %s", err)
+		}
+		if s.Title != "All Star" || s.BPM != 120 {
+			t.Errorf("b.Build() returned title %q and BPM %f, expected %q and %f", s.Title, s.BPM, "All Star", BPM(120))
+		}
+		if !s.IsDuet() {
+			t.Errorf("s.IsDuet() = false, expected true")
+		}
+		if len(s.NotesP1) != 3 || s.NotesP1[0].Text != "Some" {
+			t.Errorf("s.NotesP1 = %v, expected 3 sorted notes", s.NotesP1)
+		}
+	})
+
+	t.Run("overlapping note", func(t *testing.T) {
+		b := &SongBuilder{}
+		_ = b.AddNote(1, Note{NoteTypeRegular, 0, 6, 0, "Some"})
+		if err := b.AddNote(1, Note{NoteTypeRegular, 4, 4, 0, "body"}); !errors.Is(err, ErrOverlappingNotes) {
+			t.Errorf("b.AddNote() = %v, expected ErrOverlappingNotes", err)
+		}
+		if err := b.AddNote(2, Note{NoteTypeRegular, 4, 4, 0, "body"}); err != nil {
+			t.Errorf("b.AddNote() caused an unexpected error: %s", err)
+		}
+	})
+
+	t.Run("invalid notes", func(t *testing.T) {
+		b := &SongBuilder{}
+		if err := b.AddNote(1, Note{NoteTypeRegular, 0, -2, 0, "Some"}); !errors.Is(err, ErrNegativeDuration) {
+			t.Errorf("b.AddNote() = %v, expected ErrNegativeDuration", err)
+		}
+		if err := b.AddNote(3, Note{NoteTypeRegular, 0, 2, 0, "Some"}); !errors.Is(err, ErrInvalidPlayer) {
+			t.Errorf("b.AddNote() = %v, expected ErrInvalidPlayer", err)
+		}
+		if _, err := b.Build(); !errors.Is(err, ErrInvalidBPM) {
+			t.Errorf("b.Build() = %v, expected ErrInvalidBPM", err)
+		}
+	})
+}
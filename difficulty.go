@@ -0,0 +1,89 @@
+package ultrastar
+
+import (
+	"math"
+)
+
+// These weights are used by [Song.EstimateDifficulty].
+// You can adjust these values to tune the difficulty estimation.
+var (
+	// DifficultyDensityWeight is the weight of the note density, measured in notes per second.
+	DifficultyDensityWeight = 0.4
+	// DifficultyRangeWeight is the weight of the pitch range, measured in semitones.
+	DifficultyRangeWeight = 0.05
+	// DifficultySpeedWeight is the weight of the inverse average note duration, measured in 1/seconds.
+	DifficultySpeedWeight = 0.15
+	// DifficultyGoldenWeight is the weight of the proportion of golden notes, a value between 0 and 1.
+	DifficultyGoldenWeight = 1.0
+)
+
+// EstimateDifficulty calculates a heuristic difficulty rating of s.
+// The result is a value between 1 (easy) and 5 (hard).
+//
+// The rating is calculated as
+//
+//	1 + ⌊DifficultyDensityWeight * density
+//	  + DifficultyRangeWeight * range
+//	  + DifficultySpeedWeight / avgDuration
+//	  + DifficultyGoldenWeight * goldenRatio⌋
+//
+// where
+//   - density is the highest [Notes.Density] of all players,
+//   - range is the distance in semitones between the lowest and highest non-rap note,
+//   - avgDuration is the average duration of all notes in seconds,
+//   - goldenRatio is the proportion of golden notes among all notes.
+//
+// Line breaks and freestyle notes are not considered.
+// The result is clamped to the range from 1 to 5.
+// If s has an invalid BPM or no notes, the rating is 1.
+func (s *Song) EstimateDifficulty() int {
+	if !s.BPM.IsValid() {
+		return 1
+	}
+	var (
+		density       float64
+		count, golden int
+		totalDuration Beat
+		low, high     Pitch
+		hasPitch      bool
+	)
+	for _, ns := range []Notes{s.NotesP1, s.NotesP2} {
+		density = math.Max(density, ns.Density(s.BPM))
+		for _, n := range ns {
+			if n.Type.IsLineBreak() || n.Type.IsFreestyle() {
+				continue
+			}
+			count++
+			totalDuration += n.Duration
+			if n.Type.IsGolden() {
+				golden++
+			}
+			if n.Type.IsRap() {
+				continue
+			}
+			if !hasPitch || n.Pitch < low {
+				low = n.Pitch
+			}
+			if !hasPitch || n.Pitch > high {
+				high = n.Pitch
+			}
+			hasPitch = true
+		}
+	}
+	if count == 0 {
+		return 1
+	}
+	score := DifficultyDensityWeight*density +
+		DifficultyRangeWeight*float64(high-low) +
+		DifficultyGoldenWeight*float64(golden)/float64(count)
+	if avgDuration := s.BPM.Duration(totalDuration).Seconds() / float64(count); avgDuration > 0 {
+		score += DifficultySpeedWeight / avgDuration
+	}
+	rating := 1 + int(math.Floor(score))
+	if rating < 1 {
+		return 1
+	} else if rating > 5 {
+		return 5
+	}
+	return rating
+}
@@ -0,0 +1,32 @@
+package ultrastar
+
+import (
+	"testing"
+)
+
+func TestSong_EstimateDifficulty(t *testing.T) {
+	easy := &Song{BPM: 120, NotesP1: Notes{
+		{NoteTypeRegular, 0, 8, 0, "Some"},
+		{NoteTypeRegular, 16, 8, 2, "bo"},
+		{NoteTypeLineBreak, 32, 0, 0, "\n"},
+		{NoteTypeRegular, 40, 8, 0, "dy"},
+	}}
+	hard := &Song{BPM: 600, NotesP1: Notes{
+		{NoteTypeRegular, 0, 1, -12, "Some"},
+		{NoteTypeGolden, 1, 1, 12, "bo"},
+		{NoteTypeRegular, 2, 1, 0, "dy"},
+		{NoteTypeGolden, 3, 1, 19, "once"},
+		{NoteTypeRegular, 4, 1, -5, "told"},
+		{NoteTypeGolden, 5, 1, 7, "me"},
+	}}
+	easyRating, hardRating := easy.EstimateDifficulty(), hard.EstimateDifficulty()
+	if easyRating < 1 || easyRating > 5 {
+		t.Errorf("easy.EstimateDifficulty() = %d, expected a value between 1 and 5", easyRating)
+	}
+	if hardRating < 1 || hardRating > 5 {
+		t.Errorf("hard.EstimateDifficulty() = %d, expected a value between 1 and 5", hardRating)
+	}
+	if easyRating >= hardRating {
+		t.Errorf("easy.EstimateDifficulty() = %d, hard.EstimateDifficulty() = %d, expected easy < hard", easyRating, hardRating)
+	}
+}
@@ -3,5 +3,12 @@
 //
 // The [github.com/Karaoke-Manager/go-ultrastar/txt] subpackage implements a parser and serializer for the UltraStar TXT format.
 //
+// Some behavior of this package can be customized via package variables,
+// such as [NoteNames], [ScaleRounding], the difficulty weights (e.g. [DifficultyDensityWeight])
+// and the media extensions (e.g. [AudioExtensions]).
+// These variables are not safe for concurrent use.
+// If you want to change them you should do so during the initialization of your program,
+// before any other functions of this package are used.
+//
 // [UltraStar]: https://usdx.eu
 package ultrastar
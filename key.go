@@ -0,0 +1,83 @@
+package ultrastar
+
+import (
+	"math"
+)
+
+// These are the key profiles by Krumhansl and Kessler.
+// Each profile rates how well each pitch class fits into a key with the tonic at index 0.
+var (
+	majorKeyProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorKeyProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+// minKeyNotes is the minimum number of notes required by [Notes.EstimateKey].
+const minKeyNotes = 8
+
+// EstimateKey estimates the musical key of ns.
+// The estimation uses the Krumhansl-Schmuckler key-finding algorithm:
+// A histogram of pitch classes weighted by note duration is correlated
+// with the major and minor key profiles for every possible tonic.
+// Only sung notes (as determined by [NoteType.IsSung]) are considered.
+//
+// The result is the pitch class of the tonic of the best-matching key,
+// a value between 0 (C) and 11 (B).
+// Whether the key is major or minor is not reported.
+// If ns contains too few sung notes for a meaningful estimate, false is returned.
+func (ns Notes) EstimateKey() (Pitch, bool) {
+	var histogram [12]float64
+	count := 0
+	for _, n := range ns {
+		if n.Type.IsLineBreak() || !n.Type.IsSung() {
+			continue
+		}
+		class := int(n.Pitch) % 12
+		if class < 0 {
+			class += 12
+		}
+		histogram[class] += float64(n.Duration)
+		count++
+	}
+	if count < minKeyNotes {
+		return 0, false
+	}
+
+	tonic := 0
+	best := math.Inf(-1)
+	for i := 0; i < 12; i++ {
+		for _, profile := range [][12]float64{majorKeyProfile, minorKeyProfile} {
+			var rotated [12]float64
+			for j := range histogram {
+				rotated[j] = histogram[(i+j)%12]
+			}
+			if c := correlation(rotated, profile); c > best {
+				best = c
+				tonic = i
+			}
+		}
+	}
+	if math.IsInf(best, -1) {
+		// All correlations are NaN, e.g. because all notes have the same pitch class.
+		return 0, false
+	}
+	return Pitch(tonic), true
+}
+
+// correlation calculates the Pearson correlation coefficient of a and b.
+// If either a or b is constant, the result is NaN.
+func correlation(a, b [12]float64) float64 {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(len(a))
+	meanB /= float64(len(b))
+	var cov, varA, varB float64
+	for i := range a {
+		cov += (a[i] - meanA) * (b[i] - meanB)
+		varA += (a[i] - meanA) * (a[i] - meanA)
+		varB += (b[i] - meanB) * (b[i] - meanB)
+	}
+	return cov / math.Sqrt(varA*varB)
+}
@@ -0,0 +1,33 @@
+package ultrastar
+
+import (
+	"testing"
+)
+
+func TestNotes_EstimateKey(t *testing.T) {
+	t.Run("C major", func(t *testing.T) {
+		ns := Notes{}
+		// A C major scale with emphasis on the tonic triad
+		for i, p := range []string{"C4", "D4", "E4", "F4", "G4", "A4", "B4", "C5", "G4", "E4", "C4"} {
+			duration := Beat(2)
+			if p == "C4" || p == "C5" || p == "E4" || p == "G4" {
+				duration = 4
+			}
+			ns = append(ns, Note{NoteTypeRegular, Beat(i * 4), duration, NamedPitch(p), "la"})
+		}
+		key, ok := ns.EstimateKey()
+		if !ok {
+			t.Fatalf("ns.EstimateKey() returned false, expected true")
+		}
+		if key != 0 {
+			t.Errorf("ns.EstimateKey() = %s, expected C", key.NoteName())
+		}
+	})
+
+	t.Run("too few notes", func(t *testing.T) {
+		ns := Notes{{NoteTypeRegular, 0, 4, 0, "la"}}
+		if _, ok := ns.EstimateKey(); ok {
+			t.Errorf("ns.EstimateKey() returned true, expected false")
+		}
+	})
+}
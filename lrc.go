@@ -0,0 +1,44 @@
+package ultrastar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteTimedLyrics writes the lyrics of ns to w, one line per phrase (see [Notes.EnumerateLines]).
+// Each line is prefixed with the time of its first sung note (see [NoteType.IsSung]) in the format "[mm:ss]".
+// If a phrase has no sung notes (e.g. only rap notes), the time of its first note is used instead.
+// Times are calculated using bpm and include the specified gap.
+// Times before the start of the song are written as "[00:00]".
+// In contrast to a full LRC file there are no timestamps for individual words.
+// Phrases without any text are omitted.
+//
+// This can be useful for proofreading the lyrics of a song.
+// If bpm is invalid the result is undefined.
+func WriteTimedLyrics(w io.Writer, ns Notes, bpm BPM, gap time.Duration) error {
+	var err error
+	ns.EnumerateLines(func(line []Note, _ Beat) {
+		if err != nil {
+			return
+		}
+		text := strings.TrimSpace(Notes(line).Lyrics())
+		if len(line) == 0 || text == "" {
+			return
+		}
+		start := line[0].Start
+		for _, n := range line {
+			if n.Type.IsSung() {
+				start = n.Start
+				break
+			}
+		}
+		seconds := int((gap + bpm.Duration(start)) / time.Second)
+		if seconds < 0 {
+			seconds = 0
+		}
+		_, err = fmt.Fprintf(w, "[%02d:%02d] %s\n", seconds/60, seconds%60, text)
+	})
+	return err
+}
@@ -0,0 +1,59 @@
+package ultrastar
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTimedLyrics(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 10, 2, 0, "Some"},
+		{NoteTypeRegular, 12, 2, 0, "body "},
+		{NoteTypeLineBreak, 15, 0, 0, "\n"},
+		{NoteTypeLineBreak, 16, 0, 0, "\n"},
+		{NoteTypeRegular, 120, 2, 0, "once"},
+		{NoteTypeRegular, 122, 2, 0, " told"},
+	}
+	b := &strings.Builder{}
+	if err := WriteTimedLyrics(b, ns, 60, 5*time.Second); err != nil {
+		t.Fatalf("WriteTimedLyrics() caused an unexpected error: %s", err)
+	}
+	expected := "[00:15] Somebody\n[02:05] once told\n"
+	if b.String() != expected {
+		t.Errorf("WriteTimedLyrics() resulted in %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestWriteTimedLyrics_FirstSungNote(t *testing.T) {
+	ns := Notes{
+		{NoteTypeFreestyle, 0, 2, 0, "Oh "},
+		{NoteTypeRegular, 14, 2, 0, "some"},
+		{NoteTypeLineBreak, 16, 0, 0, "\n"},
+		{NoteTypeRap, 20, 2, 0, "body"},
+	}
+	b := &strings.Builder{}
+	if err := WriteTimedLyrics(b, ns, 60, -2*time.Second); err != nil {
+		t.Fatalf("WriteTimedLyrics() caused an unexpected error: %s", err)
+	}
+	expected := "[00:12] Oh some\n[00:18] body\n"
+	if b.String() != expected {
+		t.Errorf("WriteTimedLyrics() resulted in %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestWriteTimedLyrics_NegativeTime(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 2, 2, 0, "Some"},
+		{NoteTypeLineBreak, 4, 0, 0, "\n"},
+		{NoteTypeRegular, 14, 2, 0, "body"},
+	}
+	b := &strings.Builder{}
+	if err := WriteTimedLyrics(b, ns, 60, -10*time.Second); err != nil {
+		t.Fatalf("WriteTimedLyrics() caused an unexpected error: %s", err)
+	}
+	expected := "[00:00] Some\n[00:04] body\n"
+	if b.String() != expected {
+		t.Errorf("WriteTimedLyrics() resulted in %q, expected %q", b.String(), expected)
+	}
+}
@@ -0,0 +1,63 @@
+package ultrastar
+
+// DefaultBeatsPerBar is the number of beats in a bar of a 4/4 measure.
+// UltraStar songs usually use four beats per quarter note, so a 4/4 bar has 16 beats.
+const DefaultBeatsPerBar = 16
+
+// beatsPerBarCandidates are the bar lengths considered by [Notes.EstimateBeatsPerBar].
+// The values correspond to 4/4, 3/4 (or 6/8) and 2/4 measures.
+// The first value is preferred if multiple candidates are equally likely.
+var beatsPerBarCandidates = []Beat{DefaultBeatsPerBar, 12, 8}
+
+// minMeterNotes is the minimum number of notes required by [Notes.EstimateBeatsPerBar].
+const minMeterNotes = 8
+
+// EstimateBeatsPerBar estimates the length of a bar of ns in beats.
+// This can be used to display a metronome.
+//
+// The estimate uses the (normalized) autocorrelation of note onsets, each weighted by the duration of the note.
+// Notes starting on the first beat of a bar are usually emphasized and held longer,
+// so the autocorrelation is highest for lags that match the bar length.
+// Only bar lengths of 16, 12 and 8 beats are considered.
+//
+// If ns contains too few notes or the result is inconclusive, DefaultBeatsPerBar is returned.
+// Line breaks are not considered.
+func (ns Notes) EstimateBeatsPerBar() int {
+	onsets := make(map[Beat]float64, len(ns))
+	for _, n := range ns {
+		if n.Type.IsLineBreak() || n.Duration <= 0 {
+			continue
+		}
+		onsets[n.Start] += float64(n.Duration)
+	}
+	if len(onsets) < minMeterNotes {
+		return DefaultBeatsPerBar
+	}
+	var last Beat
+	for beat := range onsets {
+		if beat > last {
+			last = beat
+		}
+	}
+	best := Beat(DefaultBeatsPerBar)
+	bestScore := 0.0
+	for _, lag := range beatsPerBarCandidates {
+		// The score is normalized by the number of onsets that have a possible partner,
+		// so longer lags are not at a disadvantage.
+		score, count := 0.0, 0
+		for beat, w := range onsets {
+			if beat+lag <= last {
+				score += w * onsets[beat+lag]
+				count++
+			}
+		}
+		if count == 0 {
+			continue
+		}
+		score /= float64(count)
+		if score > bestScore {
+			best, bestScore = lag, score
+		}
+	}
+	return int(best)
+}
@@ -0,0 +1,37 @@
+package ultrastar
+
+import (
+	"testing"
+)
+
+func TestNotes_EstimateBeatsPerBar(t *testing.T) {
+	// bars generates count bars of the specified length.
+	// Each bar starts with a long note followed by short notes at the specified offsets.
+	bars := func(length Beat, count int, offsets ...Beat) Notes {
+		var ns Notes
+		for i := 0; i < count; i++ {
+			start := Beat(i) * length
+			ns = append(ns, Note{NoteTypeRegular, start, 6, 0, "la"})
+			for _, o := range offsets {
+				ns = append(ns, Note{NoteTypeRegular, start + o, 1, 0, "la"})
+			}
+		}
+		return ns
+	}
+	cases := map[string]struct {
+		notes    Notes
+		expected int
+	}{
+		"4/4":              {bars(16, 8, 8, 12), 16},
+		"3/4":              {bars(12, 8, 7, 10), 12},
+		"regularly spaced": {bars(4, 16), 16},
+		"too few notes":    {bars(12, 2), DefaultBeatsPerBar},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := c.notes.EstimateBeatsPerBar(); actual != c.expected {
+				t.Errorf("ns.EstimateBeatsPerBar() = %d, expected %d", actual, c.expected)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"time"
+	"unicode"
 )
 
 // A Beat is the measurement unit for notes in a song.
@@ -48,62 +50,57 @@ func (n NoteType) IsValid() bool {
 }
 
 // IsSung determines if a note is a normally sung note (golden or not).
+// For unknown note types (see [NoteType.IsValid]) the result is false.
 func (n NoteType) IsSung() bool {
 	switch n {
 	case NoteTypeRegular, NoteTypeGolden:
 		return true
-	case NoteTypeRap, NoteTypeGoldenRap, NoteTypeFreestyle, NoteTypeLineBreak:
-		return false
 	default:
-		panic("invalid note type")
+		return false
 	}
 }
 
 // IsRap determines if a note is a rap note (golden or not).
+// For unknown note types (see [NoteType.IsValid]) the result is false.
 func (n NoteType) IsRap() bool {
 	switch n {
 	case NoteTypeRap, NoteTypeGoldenRap:
 		return true
-	case NoteTypeRegular, NoteTypeGolden, NoteTypeFreestyle, NoteTypeLineBreak:
-		return false
 	default:
-		panic("invalid note type")
+		return false
 	}
 }
 
 // IsGolden determines if a note is a golden note (rap or regular).
+// For unknown note types (see [NoteType.IsValid]) the result is false.
 func (n NoteType) IsGolden() bool {
 	switch n {
 	case NoteTypeGolden, NoteTypeGoldenRap:
 		return true
-	case NoteTypeRegular, NoteTypeRap, NoteTypeFreestyle, NoteTypeLineBreak:
-		return false
 	default:
-		panic("invalid note type")
+		return false
 	}
 }
 
 // IsFreestyle determines if a note is a freestyle note.
+// For unknown note types (see [NoteType.IsValid]) the result is false.
 func (n NoteType) IsFreestyle() bool {
 	switch n {
 	case NoteTypeFreestyle:
 		return true
-	case NoteTypeRegular, NoteTypeGolden, NoteTypeRap, NoteTypeGoldenRap, NoteTypeLineBreak:
-		return false
 	default:
-		panic("invalid note type")
+		return false
 	}
 }
 
 // IsLineBreak determines if a note is a line break.
+// For unknown note types (see [NoteType.IsValid]) the result is false.
 func (n NoteType) IsLineBreak() bool {
 	switch n {
 	case NoteTypeLineBreak:
 		return true
-	case NoteTypeRegular, NoteTypeGolden, NoteTypeRap, NoteTypeGoldenRap, NoteTypeFreestyle:
-		return false
 	default:
-		panic("invalid note type")
+		return false
 	}
 }
 
@@ -142,6 +139,69 @@ func (n Note) Lyrics() string {
 	return n.Text
 }
 
+// Graphemes splits the text of n into user-perceived characters.
+// This is useful for karaoke highlighting that advances character by character.
+//
+// The segmentation is a simplified version of the extended grapheme clusters defined in UAX #29:
+// Combining marks, variation selectors, emoji modifiers and characters joined by a zero width joiner
+// are kept together with the preceding character.
+// A line break has no graphemes.
+func (n Note) Graphemes() []string {
+	if n.Type.IsLineBreak() {
+		return nil
+	}
+	var gs []string
+	start := 0
+	joined := false
+	for i, r := range n.Text {
+		if i > 0 && !joined && !extendsGrapheme(r) {
+			gs = append(gs, n.Text[start:i])
+			start = i
+		}
+		joined = r == '\u200D'
+	}
+	if start < len(n.Text) {
+		gs = append(gs, n.Text[start:])
+	}
+	return gs
+}
+
+// extendsGrapheme indicates whether r belongs to the grapheme of the preceding character.
+func extendsGrapheme(r rune) bool {
+	// U+1F3FB to U+1F3FF are the emoji skin tone modifiers.
+	return r == '\u200D' || unicode.Is(unicode.M, r) ||
+		unicode.Is(unicode.Variation_Selector, r) || (r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+// DurationSeconds returns the length of n at the specified BPM.
+// Line breaks have a duration of 0.
+// If bpm is invalid the result is undefined.
+func (n Note) DurationSeconds(bpm BPM) time.Duration {
+	if n.Type.IsLineBreak() {
+		return 0
+	}
+	return bpm.Duration(n.Duration)
+}
+
+// End returns the beat at which n ends.
+// This is n.Start + n.Duration for notes and n.Start for line breaks.
+func (n Note) End() Beat {
+	if n.Type.IsLineBreak() {
+		return n.Start
+	}
+	return n.Start + n.Duration
+}
+
+// Overlaps indicates whether n and other overlap in time.
+// Two notes overlap if each of them starts before the other one ends.
+// Line breaks have no width and never overlap with other notes.
+func (n Note) Overlaps(other Note) bool {
+	if n.Type.IsLineBreak() || other.Type.IsLineBreak() {
+		return false
+	}
+	return n.Start < other.End() && other.Start < n.End()
+}
+
 // GobEncode encodes n into a byte slice.
 func (n Note) GobEncode() ([]byte, error) {
 	var bs []byte
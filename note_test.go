@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/gob"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNoteType_IsValid(t *testing.T) {
@@ -46,7 +48,7 @@ func TestNoteType_IsSung(t *testing.T) {
 		"golden rap note": {NoteTypeGoldenRap, false, false},
 		"freestyle note":  {NoteTypeFreestyle, false, false},
 		"line break":      {NoteTypeLineBreak, false, false},
-		"invalid note":    {'#', false, true},
+		"invalid note":    {'#', false, false},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -78,7 +80,7 @@ func TestNoteType_IsRap(t *testing.T) {
 		"golden rap note": {NoteTypeGoldenRap, true, false},
 		"freestyle note":  {NoteTypeFreestyle, false, false},
 		"line break":      {NoteTypeLineBreak, false, false},
-		"invalid note":    {'#', false, true},
+		"invalid note":    {'#', false, false},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -110,7 +112,7 @@ func TestNoteType_IsGolden(t *testing.T) {
 		"golden rap note": {NoteTypeGoldenRap, true, false},
 		"freestyle note":  {NoteTypeFreestyle, false, false},
 		"line break":      {NoteTypeLineBreak, false, false},
-		"invalid note":    {'#', false, true},
+		"invalid note":    {'#', false, false},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -142,7 +144,7 @@ func TestNoteType_IsFreestyle(t *testing.T) {
 		"golden rap note": {NoteTypeGoldenRap, false, false},
 		"freestyle note":  {NoteTypeFreestyle, true, false},
 		"line break":      {NoteTypeLineBreak, false, false},
-		"invalid note":    {'#', false, true},
+		"invalid note":    {'#', false, false},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -174,7 +176,7 @@ func TestNoteType_IsLineBreak(t *testing.T) {
 		"golden rap note": {NoteTypeGoldenRap, false, false},
 		"freestyle note":  {NoteTypeFreestyle, false, false},
 		"line break":      {NoteTypeLineBreak, true, false},
-		"invalid note":    {'#', false, true},
+		"invalid note":    {'#', false, false},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -213,6 +215,90 @@ func TestNote_String(t *testing.T) {
 	}
 }
 
+func TestNote_DurationSeconds(t *testing.T) {
+	// #BPM:240 in a TXT file corresponds to 960 beats per minute.
+	bpm := BPM(960)
+	cases := map[string]struct {
+		note     Note
+		expected time.Duration
+	}{
+		"regular note": {Note{NoteTypeRegular, 0, 4, 0, "go"}, 250 * time.Millisecond},
+		"line break":   {Note{NoteTypeLineBreak, 12, 4, 0, "\n"}, 0},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := c.note.DurationSeconds(bpm)
+			if actual != c.expected {
+				t.Errorf("%v.DurationSeconds(%f) = %s, expected %s", c.note, bpm, actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestNote_End(t *testing.T) {
+	cases := map[string]struct {
+		note     Note
+		expected Beat
+	}{
+		"regular note": {Note{NoteTypeRegular, 4, 3, 0, "go"}, 7},
+		"line break":   {Note{NoteTypeLineBreak, 12, 4, 0, "\n"}, 12},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := c.note.End()
+			if actual != c.expected {
+				t.Errorf("%v.End() = %d, expected %d", c.note, actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestNote_Overlaps(t *testing.T) {
+	cases := map[string]struct {
+		a, b     Note
+		expected bool
+	}{
+		"overlapping":   {Note{NoteTypeRegular, 0, 4, 0, "go"}, Note{NoteTypeRegular, 3, 2, 0, "on"}, true},
+		"contained":     {Note{NoteTypeRegular, 0, 8, 0, "go"}, Note{NoteTypeGolden, 2, 2, 0, "on"}, true},
+		"adjacent":      {Note{NoteTypeRegular, 0, 4, 0, "go"}, Note{NoteTypeRegular, 4, 2, 0, "on"}, false},
+		"disjoint":      {Note{NoteTypeRegular, 0, 2, 0, "go"}, Note{NoteTypeRegular, 4, 2, 0, "on"}, false},
+		"line break":    {Note{NoteTypeRegular, 0, 4, 0, "go"}, Note{NoteTypeLineBreak, 2, 0, 0, "\n"}, false},
+		"zero duration": {Note{NoteTypeRegular, 0, 4, 0, "go"}, Note{NoteTypeRegular, 2, 0, 0, "on"}, true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := c.a.Overlaps(c.b); actual != c.expected {
+				t.Errorf("%v.Overlaps(%v) = %t, expected %t", c.a, c.b, actual, c.expected)
+			}
+			if actual := c.b.Overlaps(c.a); actual != c.expected {
+				t.Errorf("%v.Overlaps(%v) = %t, expected %t", c.b, c.a, actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestNote_Graphemes(t *testing.T) {
+	cases := map[string]struct {
+		text     string
+		expected []string
+	}{
+		"ascii":             {"Some", []string{"S", "o", "m", "e"}},
+		"combining accent":  {"Tra\u0308u", []string{"T", "r", "a\u0308", "u"}},
+		"emoji modifier":    {" \U0001F44D\U0001F3FD", []string{" ", "\U0001F44D\U0001F3FD"}},
+		"zero width joiner": {"\U0001F469\u200D\U0001F52C!", []string{"\U0001F469\u200D\U0001F52C", "!"}},
+		"empty":             {"", nil},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			n := Note{NoteTypeRegular, 0, 1, 0, c.text}
+			actual := n.Graphemes()
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("%v.Graphemes() = %q, expected %q", n, actual, c.expected)
+			}
+		})
+	}
+}
+
 func TestNote_GobEncode(t *testing.T) {
 	cases := map[string]Note{
 		"regular note":             Note{NoteTypeRegular, 15, 4, 8, "go"},
@@ -1,12 +1,31 @@
 package ultrastar
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"io"
 	"math"
 	"sort"
 	"strings"
 	"time"
 )
 
+// These known errors might be returned by some of the functions and methods in this package.
+var (
+	// ErrOverlappingNotes denotes that two notes overlap in time.
+	ErrOverlappingNotes = errors.New("overlapping notes")
+	// ErrNegativeDuration denotes that a note has a negative duration.
+	ErrNegativeDuration = errors.New("negative note duration")
+)
+
+// ScaleRounding is the rounding function used when scaling beats, e.g. in [Notes.Scale] and [Notes.ScaleBPM].
+// The default is [math.Round] which rounds half away from zero.
+// You can assign a different function (such as [math.Floor] or [math.RoundToEven])
+// to match the behavior of other tools.
+var ScaleRounding = math.Round
+
 // Notes represents a sequence of notes in a karaoke song.
 // This usually corresponds to the notes sung by a single player.
 //
@@ -42,6 +61,28 @@ func (ns Notes) Swap(i int, j int) {
 	ns[i], ns[j] = ns[j], ns[i]
 }
 
+// IsSorted reports whether the notes in ns are sorted by their start beat.
+func (ns Notes) IsSorted() bool {
+	return sort.IsSorted(ns)
+}
+
+// InsertionFix sorts ns using insertion sort.
+// Insertion sort is very fast if ns is already nearly sorted,
+// which is usually the case after modifying a few notes.
+// For arbitrary notes use [sort.Sort] or [sort.Stable] instead.
+//
+// The order of notes with the same start beat is preserved.
+func (ns Notes) InsertionFix() {
+	for i := 1; i < len(ns); i++ {
+		n := ns[i]
+		j := i
+		for ; j > 0 && ns[j-1].Start > n.Start; j-- {
+			ns[j] = ns[j-1]
+		}
+		ns[j] = n
+	}
+}
+
 // AddNote inserts n into m.Notes white maintaining the sort property.
 func AddNote(ns Notes, n Note) Notes {
 	i := sort.Search(len(ns), func(i int) bool {
@@ -53,6 +94,208 @@ func AddNote(ns Notes, n Note) Notes {
 	return ns
 }
 
+// Neighbors finds the notes around beat using binary search.
+// prev is the index of the last note starting at or before beat,
+// next is the index of the first note starting after beat.
+// If there is no such note, okPrev or okNext respectively is false.
+// Line breaks are treated like any other note.
+//
+// ns is expected to be sorted.
+func (ns Notes) Neighbors(beat Beat) (prev int, next int, okPrev bool, okNext bool) {
+	next = sort.Search(len(ns), func(i int) bool {
+		return ns[i].Start > beat
+	})
+	prev = next - 1
+	return prev, next, prev >= 0, next < len(ns)
+}
+
+// AlignLineBreaks inserts line breaks into a and b such that both have line breaks at the same beats.
+// For each line break in one of the arguments a line break at the same beat is inserted into the other,
+// unless it already contains a line break at that beat.
+// This is useful to display the phrases of duets side by side.
+//
+// Inserted line breaks are placed before any notes starting at the same beat.
+// a and b are expected to be sorted.
+func AlignLineBreaks(a, b *Notes) {
+	breaksA, breaksB := a.lineBreaks(), b.lineBreaks()
+	a.insertLineBreaks(breaksB)
+	b.insertLineBreaks(breaksA)
+}
+
+// lineBreaks returns the set of beats at which ns has a line break.
+func (ns Notes) lineBreaks() map[Beat]struct{} {
+	breaks := make(map[Beat]struct{})
+	for _, n := range ns {
+		if n.Type.IsLineBreak() {
+			breaks[n.Start] = struct{}{}
+		}
+	}
+	return breaks
+}
+
+// insertLineBreaks inserts a line break at each of the specified beats,
+// unless ns already has a line break at that beat.
+func (ns *Notes) insertLineBreaks(beats map[Beat]struct{}) {
+	existing := ns.lineBreaks()
+	for beat := range beats {
+		if _, ok := existing[beat]; ok {
+			continue
+		}
+		i := sort.Search(len(*ns), func(i int) bool {
+			return (*ns)[i].Start >= beat
+		})
+		*ns = append(*ns, Note{})
+		copy((*ns)[i+1:], (*ns)[i:])
+		(*ns)[i] = Note{Type: NoteTypeLineBreak, Start: beat, Text: "\n"}
+	}
+}
+
+// CanonicalizePhrases normalizes the line breaks in ns.
+// After this method returns there is exactly one line break between two consecutive lines
+// and there are no line breaks at the beginning or end of ns.
+// Each line break is positioned at the midpoint between the end of the last note
+// of a line and the start of the first note of the next line (rounded down).
+//
+// In contrast to [Notes.NormalizeLineBreaks] this method changes the start beat of line breaks.
+// ns is expected to be sorted.
+func (ns *Notes) CanonicalizePhrases() {
+	res := make(Notes, 0, len(*ns))
+	var prevEnd Beat
+	pending := false
+	for _, n := range *ns {
+		if n.Type.IsLineBreak() {
+			pending = len(res) > 0
+			continue
+		}
+		if pending {
+			res = append(res, Note{
+				Type:  NoteTypeLineBreak,
+				Start: prevEnd + (n.Start-prevEnd)/2,
+				Text:  "\n",
+			})
+			pending = false
+		}
+		res = append(res, n)
+		if end := n.Start + n.Duration; end > prevEnd || len(res) == 1 {
+			prevEnd = end
+		}
+	}
+	*ns = res
+}
+
+// MergeAdjacentSamePitch merges consecutive notes that touch each other and have the same pitch.
+// Two notes are merged if the second note starts exactly when the first note ends
+// and both notes have the same Type and Pitch.
+// The merged note spans both notes and its text is the concatenation of both texts.
+// Line breaks are never merged and prevent the merging of the notes around them.
+//
+// This can be useful to simplify over-segmented transcriptions.
+// ns is expected to be sorted.
+func (ns *Notes) MergeAdjacentSamePitch() {
+	res := make(Notes, 0, len(*ns))
+	for _, n := range *ns {
+		if len(res) > 0 {
+			prev := &res[len(res)-1]
+			if !n.Type.IsLineBreak() && prev.Type == n.Type && prev.Pitch == n.Pitch &&
+				prev.Start+prev.Duration == n.Start {
+				prev.Duration += n.Duration
+				prev.Text += n.Text
+				continue
+			}
+		}
+		res = append(res, n)
+	}
+	*ns = res
+}
+
+// PitchOutliers finds notes with an implausible pitch jump that might indicate a transcription error.
+// A note is an outlier if its pitch differs from the pitch of the previous sung note
+// by more than maxJump semitones (in either direction).
+// Only sung notes are considered (see [NoteType.IsSung]), rap and freestyle notes are skipped.
+//
+// The result contains the indexes of the outliers in ns.
+func (ns Notes) PitchOutliers(maxJump Pitch) []int {
+	var outliers []int
+	var prev Pitch
+	first := true
+	for i, n := range ns {
+		if !n.Type.IsSung() {
+			continue
+		}
+		jump := n.Pitch - prev
+		if jump < 0 {
+			jump = -jump
+		}
+		if !first && jump > maxJump {
+			outliers = append(outliers, i)
+		}
+		prev = n.Pitch
+		first = false
+	}
+	return outliers
+}
+
+// InterpolatedPitch calculates the pitch of ns at the specified beat for a smooth visualization.
+// If a sung note is active at beat, its pitch is returned.
+// If multiple sung notes overlap at beat, the pitch of the note that started last is returned.
+// Between two sung notes the pitch is linearly interpolated
+// from the end of the previous note to the start of the next note.
+// Only sung notes (as determined by [NoteType.IsSung]) are considered, rap and freestyle notes are skipped.
+//
+// If beat is before the first or after the last sung note, false is returned.
+// ns is expected to be sorted.
+func (ns Notes) InterpolatedPitch(beat Beat) (float64, bool) {
+	// active is the latest note sounding at beat,
+	// prev is the note ending last among the notes starting at or before beat.
+	var active, prev, next *Note
+	for i := range ns {
+		n := &ns[i]
+		if !n.Type.IsSung() {
+			continue
+		}
+		if beat < n.Start {
+			next = n
+			break
+		}
+		if beat <= n.End() {
+			active = n
+		}
+		if prev == nil || n.End() >= prev.End() {
+			prev = n
+		}
+	}
+	if active != nil {
+		return float64(active.Pitch), true
+	}
+	if prev == nil || next == nil {
+		return 0, false
+	}
+	t := float64(beat-prev.End()) / float64(next.Start-prev.End())
+	return float64(prev.Pitch) + t*float64(next.Pitch-prev.Pitch), true
+}
+
+// IsDegenerate detects a common corruption of exported songs where all notes start at the same beat
+// (usually beat 0).
+// Line breaks are not considered.
+// Notes with fewer than two notes are never degenerate.
+//
+// Degenerate notes cannot be played back in a meaningful way and usually cannot be repaired automatically.
+func (ns Notes) IsDegenerate() bool {
+	count := 0
+	var start Beat
+	for _, n := range ns {
+		if n.Type.IsLineBreak() {
+			continue
+		}
+		if count > 0 && n.Start != start {
+			return false
+		}
+		start = n.Start
+		count++
+	}
+	return count > 1
+}
+
 // Duration calculates the absolute duration of m, using the specified BPM.
 // The duration ignores any trailing line breaks.
 func (ns Notes) Duration(bpm BPM) time.Duration {
@@ -60,6 +303,29 @@ func (ns Notes) Duration(bpm BPM) time.Duration {
 	return bpm.Duration(lastBeat)
 }
 
+// Density calculates the number of notes per second in ns, using the specified BPM.
+// Line breaks are not counted.
+// The time span considered is from the start of the first note to the end of the last note.
+// If ns does not contain any notes, the density is 0.
+func (ns Notes) Density(bpm BPM) float64 {
+	count := 0
+	first := Beat(0)
+	for _, n := range ns {
+		if n.Type.IsLineBreak() {
+			continue
+		}
+		if count == 0 {
+			first = n.Start
+		}
+		count++
+	}
+	d := bpm.Duration(ns.LastBeat() - first).Seconds()
+	if count == 0 || d <= 0 {
+		return 0
+	}
+	return float64(count) / d
+}
+
 // LastBeat calculates the last meaningful Beat in m,
 // that is the last beat of the last non line break note.
 func (ns Notes) LastBeat() Beat {
@@ -72,6 +338,29 @@ func (ns Notes) LastBeat() Beat {
 	return 0
 }
 
+// SpacingConvention counts the notes in ns whose text starts with a space (leading)
+// and the notes whose text ends with a space (trailing).
+// A note with spaces on both sides is counted twice.
+// Line breaks are not considered.
+//
+// This can be used to determine the dominant convention of a song
+// before calling [Notes.ConvertToLeadingSpaces] or [Notes.ConvertToTrailingSpaces].
+// Only the space character is understood as whitespace.
+func (ns Notes) SpacingConvention() (leading int, trailing int) {
+	for _, n := range ns {
+		if n.Type.IsLineBreak() {
+			continue
+		}
+		if strings.HasPrefix(n.Text, " ") {
+			leading++
+		}
+		if strings.HasSuffix(n.Text, " ") {
+			trailing++
+		}
+	}
+	return leading, trailing
+}
+
 // ConvertToLeadingSpaces ensures that the text of notes does not end with a whitespace.
 // It does so by "moving" the whitespace to the neighboring notes.
 // Spaces are not moved across line breaks,
@@ -114,6 +403,59 @@ func (ns Notes) Offset(offset Beat) {
 	}
 }
 
+// ShiftBy shifts all notes by delta beats.
+// This is an alias for [Notes.Offset].
+// In duets this can be used to adjust the timing of a single player.
+func (ns Notes) ShiftBy(delta Beat) {
+	ns.Offset(delta)
+}
+
+// ShiftClamped works like [Notes.ShiftBy] but prevents notes from starting before beat 0.
+// Notes that would start before beat 0 start at beat 0 instead.
+// Durations are not modified.
+func (ns Notes) ShiftClamped(delta Beat) {
+	for i := range ns {
+		ns[i].Start += delta
+		if ns[i].Start < 0 {
+			ns[i].Start = 0
+		}
+	}
+}
+
+// PackGapless moves all notes so that each note starts spacing beats after the previous note ends.
+// The first note keeps its start beat.
+// Durations, pitches and the order of notes are preserved.
+// Line breaks are placed directly at the end of the previous note and do not introduce additional spacing.
+//
+// ns is expected to be sorted.
+func (ns Notes) PackGapless(spacing Beat) {
+	var end Beat
+	started := false
+	for i := range ns {
+		if ns[i].Type.IsLineBreak() {
+			if started {
+				ns[i].Start = end
+			}
+			continue
+		}
+		if started {
+			ns[i].Start = end + spacing
+		}
+		started = true
+		end = ns[i].Start + ns[i].Duration
+	}
+}
+
+// Transpose changes the pitch of all notes in ns by delta semitones.
+// Line breaks are not modified.
+func (ns Notes) Transpose(delta Pitch) {
+	for i := range ns {
+		if !ns[i].Type.IsLineBreak() {
+			ns[i].Pitch += delta
+		}
+	}
+}
+
 // Substitute replaces note texts that exactly match one of the texts by the specified substitute text.
 // This can be useful to replace the text of holding notes.
 func (ns Notes) Substitute(substitute string, texts ...string) {
@@ -131,23 +473,50 @@ func (ns Notes) Substitute(substitute string, texts ...string) {
 
 // Scale rescales all notes, durations and BPM changes by the specified factor.
 // This will increase or decrease the duration of m by factor.
-// All times will be rounded to the nearest integer.
+// All times will be rounded to an integer using [ScaleRounding].
 func (ns Notes) Scale(factor float64) {
 	// TODO: Test this
 	for i := range ns {
-		ns[i].Start = Beat(math.Round(float64(ns[i].Start) * factor))
-		ns[i].Duration = Beat(math.Round(float64(ns[i].Duration) * factor))
+		ns[i].Start = Beat(ScaleRounding(float64(ns[i].Start) * factor))
+		ns[i].Duration = Beat(ScaleRounding(float64(ns[i].Duration) * factor))
+	}
+}
+
+// ScaleAround works like [Notes.Scale] but scales note positions relative to anchor.
+// A note starting at anchor keeps its start beat, notes before and after it are moved
+// closer to or further away from the anchor.
+// Durations are scaled by factor.
+// All times will be rounded to an integer using [ScaleRounding].
+func (ns Notes) ScaleAround(factor float64, anchor Beat) {
+	for i := range ns {
+		ns[i].Start = anchor + Beat(ScaleRounding(float64(ns[i].Start-anchor)*factor))
+		ns[i].Duration = Beat(ScaleRounding(float64(ns[i].Duration) * factor))
 	}
 }
 
 // ScaleBPM recalculates note starts and durations to fit the specified target BPM.
 // After this method returns ns.Duration(to) is approximately equal to
 // ns.Duration(from) before this method was called.
-// Values are rounded to the nearest integer.
+// Values are rounded to an integer using [ScaleRounding].
 func (ns Notes) ScaleBPM(from BPM, to BPM) {
 	ns.Scale(float64(to / from))
 }
 
+// PartitionByScorable splits ns into scorable notes and freestyle notes.
+// Line breaks are considered part of the scorable notes.
+// Both results are new slices that keep the relative order of the notes in ns.
+// ns itself is not modified.
+func (ns Notes) PartitionByScorable() (scorable Notes, freestyle Notes) {
+	for _, n := range ns {
+		if n.Type.IsFreestyle() {
+			freestyle = append(freestyle, n)
+		} else {
+			scorable = append(scorable, n)
+		}
+	}
+	return scorable, freestyle
+}
+
 // EnumerateLines calls f for each line of the lyrics.
 // A line are the notes up to but not including a line break.
 // The Start value of the following line break is passed to f as a second parameter.
@@ -167,6 +536,62 @@ func (ns Notes) EnumerateLines(f func([]Note, Beat)) {
 	}
 }
 
+// EnumerateNotes calls f for each note in ns in order, skipping line breaks.
+// This avoids allocating an intermediate slice of notes.
+func (ns Notes) EnumerateNotes(f func(Note)) {
+	for _, n := range ns {
+		if !n.Type.IsLineBreak() {
+			f(n)
+		}
+	}
+}
+
+// EnumerateNotesInRange calls f for each note in ns in order whose Start lies in the half-open interval [from, to).
+// Line breaks are skipped.
+func (ns Notes) EnumerateNotesInRange(from, to Beat, f func(Note)) {
+	for _, n := range ns {
+		if !n.Type.IsLineBreak() && n.Start >= from && n.Start < to {
+			f(n)
+		}
+	}
+}
+
+// A PitchRange is a range of pitches from Low to High (inclusive).
+// An empty range is indicated by Low > High.
+type PitchRange struct {
+	Low  Pitch
+	High Pitch
+}
+
+// IsEmpty indicates whether r contains no pitches.
+func (r PitchRange) IsEmpty() bool {
+	return r.Low > r.High
+}
+
+// PhrasePitchRanges calculates the pitch range of each line of ns (see [Notes.EnumerateLines]).
+// Rap notes are not considered.
+// Lines without any notes with a relevant pitch produce an empty [PitchRange].
+func (ns Notes) PhrasePitchRanges() []PitchRange {
+	var ranges []PitchRange
+	ns.EnumerateLines(func(line []Note, _ Beat) {
+		r := PitchRange{Low: 0, High: -1}
+		for _, n := range line {
+			if n.Type.IsLineBreak() || n.Type.IsRap() {
+				continue
+			}
+			if r.IsEmpty() {
+				r = PitchRange{n.Pitch, n.Pitch}
+			} else if n.Pitch < r.Low {
+				r.Low = n.Pitch
+			} else if n.Pitch > r.High {
+				r.High = n.Pitch
+			}
+		}
+		ranges = append(ranges, r)
+	})
+	return ranges
+}
+
 // Lyrics generates the full lyrics of ns.
 // The full lyrics is the concatenation of the individual [Note.Lyrics] values.
 func (ns Notes) Lyrics() string {
@@ -177,3 +602,115 @@ func (ns Notes) Lyrics() string {
 	}
 	return b.String()
 }
+
+// NormalizeLineBreaks removes redundant line breaks from ns.
+// Line breaks at the beginning and the end of ns are removed
+// and consecutive line breaks are collapsed into the first one.
+//
+// ns is expected to be sorted.
+func (ns *Notes) NormalizeLineBreaks() {
+	res := (*ns)[:0]
+	for _, n := range *ns {
+		if n.Type.IsLineBreak() && (len(res) == 0 || res[len(res)-1].Type.IsLineBreak()) {
+			continue
+		}
+		res = append(res, n)
+	}
+	if len(res) > 0 && res[len(res)-1].Type.IsLineBreak() {
+		res = res[:len(res)-1]
+	}
+	*ns = res
+}
+
+// EmptyPhraseCount counts the lines of ns that do not contain any notes (see [Notes.EnumerateLines]).
+// An empty line is caused by a line break at the beginning of ns or by two consecutive line breaks.
+func (ns Notes) EmptyPhraseCount() int {
+	count := 0
+	for i, n := range ns {
+		if n.Type.IsLineBreak() && (i == 0 || ns[i-1].Type.IsLineBreak()) {
+			count++
+		}
+	}
+	return count
+}
+
+// RemoveEmptyPhrases removes the line breaks that cause empty lines in ns (see [Notes.EmptyPhraseCount]).
+// In contrast to [Notes.NormalizeLineBreaks] a line break at the end of ns is kept.
+//
+// ns is expected to be sorted.
+func (ns *Notes) RemoveEmptyPhrases() {
+	res := (*ns)[:0]
+	for _, n := range *ns {
+		if n.Type.IsLineBreak() && (len(res) == 0 || res[len(res)-1].Type.IsLineBreak()) {
+			continue
+		}
+		res = append(res, n)
+	}
+	*ns = res
+}
+
+// Equal reports whether ns and other contain the same notes in the same order.
+// A nil Notes value is equal to an empty one.
+func (ns Notes) Equal(other Notes) bool {
+	if len(ns) != len(other) {
+		return false
+	}
+	for i := range ns {
+		if ns[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Hash calculates a 64-bit FNV-1a hash of ns.
+// Equal notes produce equal hashes (see [Notes.Equal]).
+// The hash is not cryptographically secure
+// but can be used to efficiently find candidates for duplicate notes.
+func (ns Notes) Hash() uint64 {
+	h := fnv.New64a()
+	// Notes.GobEncode does not return an error.
+	bs, _ := ns.GobEncode()
+	_, _ = h.Write(bs)
+	return h.Sum64()
+}
+
+// GobEncode encodes ns into a byte slice.
+// Individual notes are encoded using [Note.GobEncode].
+func (ns Notes) GobEncode() ([]byte, error) {
+	bs := binary.AppendUvarint(nil, uint64(len(ns)))
+	for _, n := range ns {
+		nbs, err := n.GobEncode()
+		if err != nil {
+			return nil, err
+		}
+		bs = appendBytes(bs, nbs)
+	}
+	return bs, nil
+}
+
+// GobDecode updates ns from the encoded byte slice.
+func (ns *Notes) GobDecode(bs []byte) error {
+	r := bytes.NewReader(bs)
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if count > uint64(r.Len()) {
+		// Each note takes up at least one byte.
+		return io.ErrUnexpectedEOF
+	}
+	*ns = make(Notes, 0, count)
+	for i := uint64(0); i < count; i++ {
+		nbs, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		var n Note
+		if err = n.GobDecode(nbs); err != nil {
+			return err
+		}
+		*ns = append(*ns, n)
+	}
+	return nil
+}
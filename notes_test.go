@@ -1,6 +1,11 @@
 package ultrastar
 
 import (
+	"bytes"
+	"encoding/gob"
+	"math"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 )
@@ -36,3 +41,573 @@ func TestMusic_FitBPM(t *testing.T) {
 		t.Errorf("ns.Duration() changed from %s to %s, expected to stay the same", oldDuration, newDuration)
 	}
 }
+
+func TestNotes_ScaleAround(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 4, 2, 0, "Some"},
+		{NoteTypeRegular, 10, 3, 0, "body"},
+		{NoteTypeLineBreak, 14, 0, 0, "\n"},
+		{NoteTypeRegular, 16, 2, 0, "once"},
+	}
+	ns.ScaleAround(2, 10)
+	expected := Notes{
+		{NoteTypeRegular, -2, 4, 0, "Some"},
+		{NoteTypeRegular, 10, 6, 0, "body"},
+		{NoteTypeLineBreak, 18, 0, 0, "\n"},
+		{NoteTypeRegular, 22, 4, 0, "once"},
+	}
+	for i := range expected {
+		if ns[i] != expected[i] {
+			t.Errorf("ns[%d] = %v, expected %v", i, ns[i], expected[i])
+		}
+	}
+}
+
+func TestScaleRounding(t *testing.T) {
+	defer func(f func(float64) float64) { ScaleRounding = f }(ScaleRounding)
+	cases := map[string]struct {
+		rounding func(float64) float64
+		expected Notes
+	}{
+		"round": {math.Round, Notes{
+			{NoteTypeRegular, 2, 2, 0, "Some"},
+			{NoteTypeRegular, 5, 2, 0, "body"},
+		}},
+		"floor": {math.Floor, Notes{
+			{NoteTypeRegular, 1, 1, 0, "Some"},
+			{NoteTypeRegular, 4, 1, 0, "body"},
+		}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			ns := Notes{
+				{NoteTypeRegular, 3, 3, 0, "Some"},
+				{NoteTypeRegular, 9, 3, 0, "body"},
+			}
+			ScaleRounding = c.rounding
+			ns.Scale(0.5)
+			if !ns.Equal(c.expected) {
+				t.Errorf("ns.Scale(0.5) resulted in %v, expected %v", ns, c.expected)
+			}
+		})
+	}
+}
+
+func TestNotes_GobEncode(t *testing.T) {
+	cases := map[string]Notes{
+		"no notes": {},
+		"mixed notes": {
+			{NoteTypeRegular, 0, 6, 6, "Some"},
+			{NoteTypeGolden, 12, 4, 13, "bo"},
+			{NoteTypeRap, 18, 3, 10, "dy "},
+			{NoteTypeLineBreak, 22, 0, 0, "\n"},
+			{NoteTypeGoldenRap, 24, 5, 10, "once "},
+			{NoteTypeFreestyle, 36, 4, -8, "told "},
+		},
+	}
+	for name, notes := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := gob.NewEncoder(buf).Encode(notes); err != nil {
+				t.Fatalf("GobEncode() caused an unexpected error: %s", err)
+			}
+			var ns Notes
+			if err := gob.NewDecoder(buf).Decode(&ns); err != nil {
+				t.Fatalf("GobDecode() caused an unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(ns, notes) {
+				t.Errorf("GobDecode(GobEncode(ns)) = %v, expected %v", ns, notes)
+			}
+		})
+	}
+}
+
+func TestNotes_Density(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 10, 5, 0, "Some"},
+		{NoteTypeLineBreak, 15, 0, 0, "\n"},
+		{NoteTypeRegular, 20, 10, 0, "body"},
+	}
+	// 2 notes in 20 beats at 60 BPM
+	expected := 0.1
+	actual := ns.Density(60)
+	if actual != expected {
+		t.Errorf("ns.Density(60) = %f, expected %f", actual, expected)
+	}
+}
+
+func TestNotes_PartitionByScorable(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeFreestyle, 2, 2, 0, "body"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeGolden, 6, 2, 0, "once"},
+		{NoteTypeFreestyle, 8, 2, 0, "told"},
+	}
+	scorable, freestyle := ns.PartitionByScorable()
+	expectedScorable := Notes{ns[0], ns[2], ns[3]}
+	expectedFreestyle := Notes{ns[1], ns[4]}
+	if !reflect.DeepEqual(scorable, expectedScorable) {
+		t.Errorf("ns.PartitionByScorable() returned scorable notes %v, expected %v", scorable, expectedScorable)
+	}
+	if !reflect.DeepEqual(freestyle, expectedFreestyle) {
+		t.Errorf("ns.PartitionByScorable() returned freestyle notes %v, expected %v", freestyle, expectedFreestyle)
+	}
+}
+
+func TestNotes_EnumerateNotes(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeFreestyle, 2, 2, 0, "body"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeGolden, 6, 2, 0, "once"},
+	}
+	var actual Notes
+	ns.EnumerateNotes(func(n Note) {
+		actual = append(actual, n)
+	})
+	expected := Notes{ns[0], ns[1], ns[3]}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ns.EnumerateNotes() enumerated %v, expected %v", actual, expected)
+	}
+}
+
+func TestNotes_EnumerateNotesInRange(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeFreestyle, 2, 2, 0, "body"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeGolden, 6, 2, 0, "once"},
+		{NoteTypeRegular, 8, 2, 0, "told"},
+	}
+	var actual Notes
+	ns.EnumerateNotesInRange(2, 8, func(n Note) {
+		actual = append(actual, n)
+	})
+	expected := Notes{ns[1], ns[3]}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ns.EnumerateNotesInRange(2, 8) enumerated %v, expected %v", actual, expected)
+	}
+}
+
+func TestNotes_PackGapless(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 4, 2, 3, "Some"},
+		{NoteTypeRegular, 10, 3, 5, "body"},
+		{NoteTypeLineBreak, 16, 0, 0, "\n"},
+		{NoteTypeGolden, 30, 2, 7, "once"},
+		{NoteTypeRegular, 40, 1, 8, "told"},
+	}
+	ns.PackGapless(1)
+	expected := Notes{
+		{NoteTypeRegular, 4, 2, 3, "Some"},
+		{NoteTypeRegular, 7, 3, 5, "body"},
+		{NoteTypeLineBreak, 10, 0, 0, "\n"},
+		{NoteTypeGolden, 11, 2, 7, "once"},
+		{NoteTypeRegular, 14, 1, 8, "told"},
+	}
+	if !reflect.DeepEqual(ns, expected) {
+		t.Errorf("ns.PackGapless(1) resulted in %v, expected %v", ns, expected)
+	}
+}
+
+func TestNotes_Equal(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeGolden, 6, 2, 0, "body"},
+	}
+	cases := map[string]struct {
+		a, b     Notes
+		expected bool
+	}{
+		"equal":            {ns, Notes{ns[0], ns[1], ns[2]}, true},
+		"different text":   {ns, Notes{ns[0], ns[1], {NoteTypeGolden, 6, 2, 0, "bodies"}}, false},
+		"different length": {ns, ns[:2], false},
+		"both nil":         {nil, nil, true},
+		"nil and empty":    {nil, Notes{}, true},
+		"nil and notes":    {nil, ns, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := c.a.Equal(c.b); actual != c.expected {
+				t.Errorf("a.Equal(b) = %t, expected %t", actual, c.expected)
+			}
+			if c.expected && c.a.Hash() != c.b.Hash() {
+				t.Errorf("a.Hash() = %d, b.Hash() = %d, expected equal hashes", c.a.Hash(), c.b.Hash())
+			}
+		})
+	}
+}
+
+func TestAlignLineBreaks(t *testing.T) {
+	a := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeLineBreak, 4, 0, 0, "\n"},
+		{NoteTypeRegular, 6, 2, 0, "body"},
+		{NoteTypeLineBreak, 10, 0, 0, "\n"},
+		{NoteTypeRegular, 12, 2, 0, "once"},
+	}
+	b := Notes{
+		{NoteTypeRegular, 1, 2, 0, "told"},
+		{NoteTypeLineBreak, 4, 0, 0, "\n"},
+		{NoteTypeRegular, 8, 2, 0, "me"},
+		{NoteTypeRegular, 12, 2, 0, "the"},
+		{NoteTypeLineBreak, 16, 0, 0, "\n"},
+		{NoteTypeRegular, 18, 2, 0, "world"},
+	}
+	AlignLineBreaks(&a, &b)
+	expectedA := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeLineBreak, 4, 0, 0, "\n"},
+		{NoteTypeRegular, 6, 2, 0, "body"},
+		{NoteTypeLineBreak, 10, 0, 0, "\n"},
+		{NoteTypeRegular, 12, 2, 0, "once"},
+		{NoteTypeLineBreak, 16, 0, 0, "\n"},
+	}
+	expectedB := Notes{
+		{NoteTypeRegular, 1, 2, 0, "told"},
+		{NoteTypeLineBreak, 4, 0, 0, "\n"},
+		{NoteTypeRegular, 8, 2, 0, "me"},
+		{NoteTypeLineBreak, 10, 0, 0, "\n"},
+		{NoteTypeRegular, 12, 2, 0, "the"},
+		{NoteTypeLineBreak, 16, 0, 0, "\n"},
+		{NoteTypeRegular, 18, 2, 0, "world"},
+	}
+	if !reflect.DeepEqual(a, expectedA) {
+		t.Errorf("AlignLineBreaks(a, b) resulted in a = %v, expected %v", a, expectedA)
+	}
+	if !reflect.DeepEqual(b, expectedB) {
+		t.Errorf("AlignLineBreaks(a, b) resulted in b = %v, expected %v", b, expectedB)
+	}
+}
+
+func TestNotes_PhrasePitchRanges(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 4, "Some"},
+		{NoteTypeGolden, 2, 2, -1, "bo"},
+		{NoteTypeRap, 4, 2, 20, "dy"},
+		{NoteTypeLineBreak, 7, 0, 0, "\n"},
+		{NoteTypeRap, 8, 2, 0, "once"},
+		{NoteTypeLineBreak, 11, 0, 0, "\n"},
+		{NoteTypeRegular, 12, 2, 7, "told"},
+		{NoteTypeRegular, 14, 2, 9, "me"},
+	}
+	actual := ns.PhrasePitchRanges()
+	expected := []PitchRange{{-1, 4}, {0, -1}, {7, 9}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ns.PhrasePitchRanges() = %v, expected %v", actual, expected)
+	}
+	if !actual[1].IsEmpty() {
+		t.Errorf("actual[1].IsEmpty() = false, expected true")
+	}
+}
+
+func TestNotes_CanonicalizePhrases(t *testing.T) {
+	ns := Notes{
+		{NoteTypeLineBreak, 0, 0, 0, "\n"},
+		{NoteTypeRegular, 2, 2, 0, "Some"},
+		{NoteTypeRegular, 4, 2, 0, "body"},
+		{NoteTypeLineBreak, 6, 0, 0, "\n"},
+		{NoteTypeLineBreak, 7, 0, 0, "\n"},
+		{NoteTypeRegular, 12, 2, 0, "once"},
+		{NoteTypeLineBreak, 14, 0, 0, "\n"},
+		{NoteTypeRegular, 15, 2, 0, "told"},
+		{NoteTypeLineBreak, 20, 0, 0, "\n"},
+	}
+	ns.CanonicalizePhrases()
+	expected := Notes{
+		{NoteTypeRegular, 2, 2, 0, "Some"},
+		{NoteTypeRegular, 4, 2, 0, "body"},
+		{NoteTypeLineBreak, 9, 0, 0, "\n"},
+		{NoteTypeRegular, 12, 2, 0, "once"},
+		{NoteTypeLineBreak, 14, 0, 0, "\n"},
+		{NoteTypeRegular, 15, 2, 0, "told"},
+	}
+	if !reflect.DeepEqual(ns, expected) {
+		t.Errorf("ns.CanonicalizePhrases() resulted in %v, expected %v", ns, expected)
+	}
+}
+
+func TestNotes_IsDegenerate(t *testing.T) {
+	cases := map[string]struct {
+		notes    Notes
+		expected bool
+	}{
+		"degenerate": {Notes{
+			{NoteTypeRegular, 0, 2, 0, "Some"},
+			{NoteTypeRegular, 0, 3, 0, "body"},
+			{NoteTypeLineBreak, 5, 0, 0, "\n"},
+			{NoteTypeRegular, 0, 1, 0, "once"},
+		}, true},
+		"normal": {Notes{
+			{NoteTypeRegular, 0, 2, 0, "Some"},
+			{NoteTypeRegular, 2, 3, 0, "body"},
+			{NoteTypeLineBreak, 5, 0, 0, "\n"},
+			{NoteTypeRegular, 6, 1, 0, "once"},
+		}, false},
+		"single note": {Notes{{NoteTypeRegular, 0, 2, 0, "Some"}}, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := c.notes.IsDegenerate(); actual != c.expected {
+				t.Errorf("ns.IsDegenerate() = %t, expected %t", actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestNotes_MergeAdjacentSamePitch(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 0, "So"},
+		{NoteTypeRegular, 2, 2, 0, "me"},
+		{NoteTypeRegular, 4, 2, 2, "bo"},
+		{NoteTypeGolden, 6, 2, 2, "dy"},
+		{NoteTypeLineBreak, 8, 0, 0, "\n"},
+		{NoteTypeRegular, 8, 2, 2, "once"},
+		{NoteTypeRegular, 11, 2, 2, " told"},
+	}
+	ns.MergeAdjacentSamePitch()
+	expected := Notes{
+		{NoteTypeRegular, 0, 4, 0, "Some"},
+		{NoteTypeRegular, 4, 2, 2, "bo"},
+		{NoteTypeGolden, 6, 2, 2, "dy"},
+		{NoteTypeLineBreak, 8, 0, 0, "\n"},
+		{NoteTypeRegular, 8, 2, 2, "once"},
+		{NoteTypeRegular, 11, 2, 2, " told"},
+	}
+	if !reflect.DeepEqual(ns, expected) {
+		t.Errorf("ns.MergeAdjacentSamePitch() resulted in %v, expected %v", ns, expected)
+	}
+}
+
+func TestNotes_PitchOutliers(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeRegular, 2, 2, 2, "bo"},
+		{NoteTypeLineBreak, 4, 0, 0, "\n"},
+		{NoteTypeRap, 5, 2, 30, "dy"},
+		{NoteTypeGolden, 7, 2, 26, "once"},
+		{NoteTypeRegular, 9, 2, 24, "told"},
+	}
+	actual := ns.PitchOutliers(12)
+	expected := []int{4}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ns.PitchOutliers(12) = %v, expected %v", actual, expected)
+	}
+}
+
+func TestNotes_InsertionFix(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeRegular, 6, 2, 0, "once"},
+		{NoteTypeRegular, 3, 2, 0, "body"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeRegular, 5, 1, 0, "told"},
+	}
+	if ns.IsSorted() {
+		t.Errorf("ns.IsSorted() = true, expected false")
+	}
+	ns.InsertionFix()
+	expected := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeRegular, 3, 2, 0, "body"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeRegular, 5, 1, 0, "told"},
+		{NoteTypeRegular, 6, 2, 0, "once"},
+	}
+	if !reflect.DeepEqual(ns, expected) {
+		t.Errorf("ns.InsertionFix() resulted in %v, expected %v", ns, expected)
+	}
+	if !ns.IsSorted() {
+		t.Errorf("ns.IsSorted() = false, expected true")
+	}
+}
+
+// nearlySortedNotes returns n sorted notes where every 100th note is swapped with its successor.
+func nearlySortedNotes(n int) Notes {
+	ns := make(Notes, n)
+	for i := range ns {
+		ns[i] = Note{NoteTypeRegular, Beat(2 * i), 1, 0, "la"}
+	}
+	for i := 0; i+1 < n; i += 100 {
+		ns[i], ns[i+1] = ns[i+1], ns[i]
+	}
+	return ns
+}
+
+func BenchmarkNotes_InsertionFix(b *testing.B) {
+	ns := nearlySortedNotes(1000)
+	work := make(Notes, len(ns))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(work, ns)
+		work.InsertionFix()
+	}
+}
+
+func BenchmarkNotes_Sort(b *testing.B) {
+	ns := nearlySortedNotes(1000)
+	work := make(Notes, len(ns))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(work, ns)
+		sort.Stable(work)
+	}
+}
+
+func TestNotes_RemoveEmptyPhrases(t *testing.T) {
+	ns := Notes{
+		{NoteTypeLineBreak, 0, 0, 0, "\n"},
+		{NoteTypeRegular, 2, 2, 0, "Some"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeLineBreak, 6, 0, 0, "\n"},
+		{NoteTypeRegular, 8, 2, 0, "body"},
+		{NoteTypeLineBreak, 11, 0, 0, "\n"},
+	}
+	if count := ns.EmptyPhraseCount(); count != 2 {
+		t.Errorf("ns.EmptyPhraseCount() = %d, expected 2", count)
+	}
+	ns.RemoveEmptyPhrases()
+	expected := Notes{
+		{NoteTypeRegular, 2, 2, 0, "Some"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeRegular, 8, 2, 0, "body"},
+		{NoteTypeLineBreak, 11, 0, 0, "\n"},
+	}
+	if !reflect.DeepEqual(ns, expected) {
+		t.Errorf("ns.RemoveEmptyPhrases() resulted in %v, expected %v", ns, expected)
+	}
+	if count := ns.EmptyPhraseCount(); count != 0 {
+		t.Errorf("ns.EmptyPhraseCount() = %d, expected 0", count)
+	}
+}
+
+func TestNotes_ShiftClamped(t *testing.T) {
+	s := &Song{
+		NotesP1: Notes{
+			{NoteTypeRegular, 2, 2, 0, "Some"},
+			{NoteTypeRegular, 6, 2, 0, "body"},
+		},
+		NotesP2: Notes{
+			{NoteTypeRegular, 2, 2, 0, "once"},
+			{NoteTypeLineBreak, 5, 0, 0, "\n"},
+			{NoteTypeRegular, 6, 2, 0, "told"},
+		},
+	}
+	s.NotesP2.ShiftClamped(-3)
+	expectedP1 := Notes{
+		{NoteTypeRegular, 2, 2, 0, "Some"},
+		{NoteTypeRegular, 6, 2, 0, "body"},
+	}
+	expectedP2 := Notes{
+		{NoteTypeRegular, 0, 2, 0, "once"},
+		{NoteTypeLineBreak, 2, 0, 0, "\n"},
+		{NoteTypeRegular, 3, 2, 0, "told"},
+	}
+	if !reflect.DeepEqual(s.NotesP1, expectedP1) {
+		t.Errorf("s.NotesP2.ShiftClamped(-3) changed s.NotesP1 to %v, expected %v", s.NotesP1, expectedP1)
+	}
+	if !reflect.DeepEqual(s.NotesP2, expectedP2) {
+		t.Errorf("s.NotesP2.ShiftClamped(-3) resulted in %v, expected %v", s.NotesP2, expectedP2)
+	}
+
+	s.NotesP2.ShiftBy(2)
+	if s.NotesP2[0].Start != 2 || s.NotesP2[2].Start != 5 {
+		t.Errorf("s.NotesP2.ShiftBy(2) resulted in %v, expected starts 2, 4, 5", s.NotesP2)
+	}
+}
+
+func TestNotes_SpacingConvention(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeRegular, 2, 2, 0, "body "},
+		{NoteTypeRegular, 4, 2, 0, "once "},
+		{NoteTypeLineBreak, 7, 0, 0, "\n"},
+		{NoteTypeRegular, 8, 2, 0, " told"},
+		{NoteTypeRegular, 10, 2, 0, " me "},
+	}
+	leading, trailing := ns.SpacingConvention()
+	if leading != 2 || trailing != 3 {
+		t.Errorf("ns.SpacingConvention() = %d, %d, expected 2, 3", leading, trailing)
+	}
+}
+
+func TestNotes_InterpolatedPitch(t *testing.T) {
+	c4, c5 := NamedPitch("C4"), NamedPitch("C5")
+	ns := Notes{
+		{NoteTypeRegular, 0, 4, c4, "Some"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeRap, 6, 2, c4 - 12, "bo"},
+		{NoteTypeGolden, 12, 4, c5, "dy"},
+	}
+	cases := map[string]struct {
+		beat     Beat
+		expected float64
+		ok       bool
+	}{
+		"inside note": {2, float64(c4), true},
+		"halfway":     {8, float64(c4+c5) / 2, true},
+		"end of note": {16, float64(c5), true},
+		"before":      {-1, 0, false},
+		"after":       {17, 0, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, ok := ns.InterpolatedPitch(c.beat)
+			if actual != c.expected || ok != c.ok {
+				t.Errorf("ns.InterpolatedPitch(%d) = %f, %t, expected %f, %t", c.beat, actual, ok, c.expected, c.ok)
+			}
+		})
+	}
+
+	overlapping := Notes{
+		{NoteTypeRegular, 0, 8, 0, "Some"},
+		{NoteTypeRegular, 2, 2, 5, "bo"},
+		{NoteTypeRegular, 12, 2, 2, "dy"},
+	}
+	overlapCases := map[string]struct {
+		beat     Beat
+		expected float64
+	}{
+		"both active":      {3, 5},
+		"outer note only":  {6, 0},
+		"after outer note": {10, 1},
+	}
+	for name, c := range overlapCases {
+		t.Run(name, func(t *testing.T) {
+			actual, ok := overlapping.InterpolatedPitch(c.beat)
+			if actual != c.expected || !ok {
+				t.Errorf("ns.InterpolatedPitch(%d) = %f, %t, expected %f, true", c.beat, actual, ok, c.expected)
+			}
+		})
+	}
+}
+
+func TestNotes_Neighbors(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 2, 2, 0, "Some"},
+		{NoteTypeRegular, 4, 2, 0, "body"},
+		{NoteTypeLineBreak, 7, 0, 0, "\n"},
+		{NoteTypeRegular, 10, 2, 0, "once"},
+	}
+	cases := map[string]struct {
+		beat           Beat
+		prev, next     int
+		okPrev, okNext bool
+	}{
+		"between notes": {8, 2, 3, true, true},
+		"at note start": {4, 1, 2, true, true},
+		"before first":  {1, -1, 0, false, true},
+		"after last":    {12, 3, 4, true, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			prev, next, okPrev, okNext := ns.Neighbors(c.beat)
+			if prev != c.prev || next != c.next || okPrev != c.okPrev || okNext != c.okNext {
+				t.Errorf("ns.Neighbors(%d) = %d, %d, %t, %t, expected %d, %d, %t, %t",
+					c.beat, prev, next, okPrev, okNext, c.prev, c.next, c.okPrev, c.okNext)
+			}
+		})
+	}
+}
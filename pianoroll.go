@@ -0,0 +1,45 @@
+package ultrastar
+
+import (
+	"math"
+)
+
+// RapPitch is the pitch used by [Notes.PianoRoll] for rap notes.
+// Rap notes have no meaningful pitch, so renderers usually draw them on a separate lane.
+const RapPitch = Pitch(math.MinInt32)
+
+// A PianoRollBar is a horizontal bar in a piano roll representation of a song.
+type PianoRollBar struct {
+	// X0 is the start of the bar in seconds.
+	X0 float64
+	// X1 is the end of the bar in seconds.
+	X1 float64
+	// Pitch is the vertical position of the bar.
+	// For rap notes this is RapPitch.
+	Pitch Pitch
+}
+
+// PianoRoll calculates the geometry of a piano roll representation of ns, using the specified BPM.
+// Each note except line breaks is represented by a bar from the start to the end of the note.
+// Times are measured in seconds from beat 0.
+// Bars are returned in the order of the notes in ns.
+//
+// If bpm is invalid the result is undefined.
+func (ns Notes) PianoRoll(bpm BPM) []PianoRollBar {
+	bars := make([]PianoRollBar, 0, len(ns))
+	for _, n := range ns {
+		if n.Type.IsLineBreak() {
+			continue
+		}
+		bar := PianoRollBar{
+			X0:    bpm.Duration(n.Start).Seconds(),
+			X1:    bpm.Duration(n.Start + n.Duration).Seconds(),
+			Pitch: n.Pitch,
+		}
+		if n.Type.IsRap() {
+			bar.Pitch = RapPitch
+		}
+		bars = append(bars, bar)
+	}
+	return bars
+}
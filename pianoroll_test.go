@@ -0,0 +1,24 @@
+package ultrastar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNotes_PianoRoll(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 2, 3, "Some"},
+		{NoteTypeLineBreak, 3, 0, 0, "\n"},
+		{NoteTypeGolden, 4, 4, -2, "bo"},
+		{NoteTypeRap, 8, 1, 5, "dy"},
+	}
+	actual := ns.PianoRoll(120)
+	expected := []PianoRollBar{
+		{0, 1, 3},
+		{2, 4, -2},
+		{4, 4.5, RapPitch},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ns.PianoRoll(120) = %v, expected %v", actual, expected)
+	}
+}
@@ -14,8 +14,19 @@ var (
 // A Pitch represents the pitch of a note.
 type Pitch int
 
-// noteNames are the names of notes used for pitches. See [Pitch.NoteName] for details.
-var noteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+// These are predefined note name tables that can be assigned to [NoteNames].
+var (
+	// EnglishNoteNames are the english names of notes.
+	EnglishNoteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	// GermanNoteNames are the german names of notes.
+	// In german notation the english B is called H and the english B flat (or A#) is called B.
+	GermanNoteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "B", "H"}
+)
+
+// NoteNames are the names of notes used by [Pitch.NoteName] and [Pitch.String].
+// You can assign a different table (e.g. GermanNoteNames) to localize note names.
+// Parsing pitches via [PitchFromString] always uses EnglishNoteNames.
+var NoteNames = EnglishNoteNames
 
 // NamedPitch works like [PitchFromString] but panics if the pitch cannot be parsed.
 // This can be useful for testing or for compile-time constant pitches.
@@ -29,8 +40,11 @@ func NamedPitch(s string) Pitch {
 
 // PitchFromString returns a new pitch based on the string representation of a pitch.
 func PitchFromString(s string) (p Pitch, err error) {
+	if s == "" {
+		return p, ErrInvalidPitchName
+	}
 	ok := false
-	for index, note := range noteNames {
+	for index, note := range EnglishNoteNames {
 		if note == string(s[0]) {
 			p = Pitch(index)
 			ok = true
@@ -39,35 +53,35 @@ func PitchFromString(s string) (p Pitch, err error) {
 	if !ok {
 		return p, ErrInvalidPitchName
 	}
-	var rest string
-	switch s[1] {
-	case '#':
-		p += 1
-		rest = s[2:]
-	case 'b':
-		p -= 1
-		rest = s[2:]
-	default:
-		rest = s[1:]
+	rest := s[1:]
+	if rest != "" {
+		switch rest[0] {
+		case '#':
+			p += 1
+			rest = rest[1:]
+		case 'b':
+			p -= 1
+			rest = rest[1:]
+		}
 	}
 	octave, err := strconv.Atoi(rest)
 	if err != nil {
 		octave = 4
 	}
-	p = Pitch(int(p) + (octave-4)*len(noteNames))
+	p = Pitch(int(p) + (octave-4)*len(EnglishNoteNames))
 	return p, nil
 }
 
-// NoteName returns the human-readable name of the pitch.
+// NoteName returns the human-readable name of the pitch as defined by [NoteNames].
 // The note naming is not very sophisticated.
 // Only whole and half steps are supported and note names use sharps exclusively.
 // So a D flat and a C sharp will both return "C#" as their note name.
 func (p Pitch) NoteName() string {
-	i := int(p) % len(noteNames)
+	i := int(p) % len(NoteNames)
 	if i < 0 {
-		i += len(noteNames)
+		i += len(NoteNames)
 	}
-	return noteNames[i]
+	return NoteNames[i]
 }
 
 // Octave returns the [scientific octave] of a pitch.
@@ -75,11 +89,37 @@ func (p Pitch) NoteName() string {
 // [scientific octave]: https://en.wikipedia.org/wiki/Octave#Notation
 func (p Pitch) Octave() int {
 	// FIXME: Is 0 actually C4?
-	octave := (int(p) / len(noteNames)) + 4
-	if p < 0 {
-		octave -= 1
+	// Round towards negative infinity so that e.g. -12 is C3 and -1 is B3.
+	octave := int(p) / len(NoteNames)
+	if int(p)%len(NoteNames) < 0 {
+		octave--
+	}
+	return octave + 4
+}
+
+// Transpose returns the pitch that is the specified number of semitones above p.
+// Negative values transpose p downwards.
+func (p Pitch) Transpose(semitones int) Pitch {
+	return p + Pitch(semitones)
+}
+
+// Interval returns the signed distance from p to other in semitones.
+// The result is positive if other is higher than p.
+func (p Pitch) Interval(other Pitch) int {
+	return int(other - p)
+}
+
+// TransposeToOctave returns the pitch with the same note name as p in the specified [scientific octave].
+// The result is consistent with [PitchFromString] and [Pitch.Octave],
+// so p.TransposeToOctave(o).Octave() == o for all pitches.
+//
+// [scientific octave]: https://en.wikipedia.org/wiki/Octave#Notation
+func (p Pitch) TransposeToOctave(octave int) Pitch {
+	class := int(p) % len(NoteNames)
+	if class < 0 {
+		class += len(NoteNames)
 	}
-	return octave
+	return Pitch(class + (octave-4)*len(NoteNames))
 }
 
 // String returns a human-readable string representation of the pitch.
@@ -25,6 +25,28 @@ func TestPitch_NoteName(t *testing.T) {
 	}
 }
 
+func TestPitch_NoteName_German(t *testing.T) {
+	defer func() { NoteNames = EnglishNoteNames }()
+	NoteNames = GermanNoteNames
+	cases := map[string]struct {
+		pitch    Pitch
+		expected string
+	}{
+		"C4":  {0, "C"},
+		"A#4": {10, "B"},
+		"B4":  {11, "H"},
+		"B3":  {-1, "H"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := c.pitch.NoteName()
+			if actual != c.expected {
+				t.Errorf("%d.NoteName() = %q, expected %q", c.pitch, actual, c.expected)
+			}
+		})
+	}
+}
+
 func ExamplePitch_NoteName() {
 	fmt.Println(NamedPitch("Gb4").NoteName())
 	// Output: F#
@@ -41,7 +63,9 @@ func TestPitch_Octave(t *testing.T) {
 		"C#5": {13, 5},
 		"B3":  {-1, 3},
 		"C#3": {-11, 3},
-		"C2":  {-12, 2},
+		"C3":  {-12, 3},
+		"B2":  {-13, 2},
+		"C2":  {-24, 2},
 	}
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
@@ -58,6 +82,85 @@ func ExamplePitch_Octave() {
 	// Output: 4
 }
 
+func TestPitch_Transpose(t *testing.T) {
+	cases := map[string]struct {
+		pitch     Pitch
+		semitones int
+		expected  Pitch
+	}{
+		"C4 to C5":  {0, 12, 12},
+		"B4 to C5":  {11, 1, 12},
+		"C4 to B3":  {0, -1, -1},
+		"C#3 to C2": {-11, -13, -24},
+		"no change": {5, 0, 5},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := c.pitch.Transpose(c.semitones)
+			if actual != c.expected {
+				t.Errorf("%q.Transpose(%d) = %q, expected %q", c.pitch, c.semitones, actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestPitch_Interval(t *testing.T) {
+	cases := map[string]struct {
+		pitch    Pitch
+		other    Pitch
+		expected int
+	}{
+		"C4 to C5":  {0, 12, 12},
+		"C5 to C4":  {12, 0, -12},
+		"B3 to C4":  {-1, 0, 1},
+		"C#3 to C2": {-11, -24, -13},
+		"unison":    {7, 7, 0},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := c.pitch.Interval(c.other)
+			if actual != c.expected {
+				t.Errorf("%q.Interval(%q) = %d, expected %d", c.pitch, c.other, actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestPitch_TransposeToOctave(t *testing.T) {
+	cases := map[string]struct {
+		pitch    string
+		octave   int
+		expected string
+	}{
+		"C4 to C5":   {"C4", 5, "C5"},
+		"B4 to B3":   {"B4", 3, "B3"},
+		"C#3 to C#6": {"C#3", 6, "C#6"},
+		"B3 to B1":   {"B3", 1, "B1"},
+		"A4 to A4":   {"A4", 4, "A4"},
+		"C4 to C3":   {"C4", 3, "C3"},
+		"C3 to C2":   {"C3", 2, "C2"},
+		"C5 to C3":   {"C5", 3, "C3"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := NamedPitch(c.pitch).TransposeToOctave(c.octave)
+			expected := NamedPitch(c.expected)
+			if actual != expected {
+				t.Errorf("%q.TransposeToOctave(%d) = %d, expected %d", c.pitch, c.octave, actual, expected)
+			}
+			if actual.Octave() != c.octave {
+				t.Errorf("%q.TransposeToOctave(%d).Octave() = %d, expected %d", c.pitch, c.octave, actual.Octave(), c.octave)
+			}
+			if actual.String() != c.expected {
+				t.Errorf("%q.TransposeToOctave(%d).String() = %q, expected %q", c.pitch, c.octave, actual.String(), c.expected)
+			}
+			if actual.NoteName() != NamedPitch(c.pitch).NoteName() {
+				t.Errorf("%q.TransposeToOctave(%d).NoteName() = %q, expected %q", c.pitch, c.octave, actual.NoteName(), NamedPitch(c.pitch).NoteName())
+			}
+		})
+	}
+}
+
 func TestParsePitch(t *testing.T) {
 	cases := map[string]struct {
 		expected    Pitch
@@ -68,7 +171,9 @@ func TestParsePitch(t *testing.T) {
 		"Db4":   {1, false},
 		"A2":    {-15, false},
 		"C#5":   {13, false},
+		"C":     {0, false},
 		"Hello": {0, true},
+		"":      {0, true},
 	}
 	for raw, c := range cases {
 		t.Run(raw, func(t *testing.T) {
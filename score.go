@@ -0,0 +1,53 @@
+package ultrastar
+
+import (
+	"math"
+)
+
+// MaxScore is the number of points that can be achieved by singing all notes of a player perfectly.
+// This corresponds to the score of UltraStar without the line bonus.
+const MaxScore = 10000
+
+// NotePoints distributes [MaxScore] points across the notes of ns, similar to UltraStar.
+// The points of a note are proportional to its duration.
+// Golden notes (including golden rap notes) count twice as much as regular notes.
+// Freestyle notes and line breaks award no points.
+//
+// The result contains the points for each note in ns at the same index.
+// If ns contains no scorable notes, all values are 0.
+func (ns Notes) NotePoints() []float64 {
+	points := make([]float64, len(ns))
+	var total Beat
+	for _, n := range ns {
+		total += n.scoreWeight()
+	}
+	if total <= 0 {
+		return points
+	}
+	for i, n := range ns {
+		points[i] = MaxScore * float64(n.scoreWeight()) / float64(total)
+	}
+	return points
+}
+
+// MaxPoints calculates the maximum number of points that can be achieved when singing ns.
+// This is the sum of [Notes.NotePoints], rounded to the nearest integer.
+// The result is [MaxScore] unless ns contains no scorable notes, in which case it is 0.
+func (ns Notes) MaxPoints() int {
+	sum := 0.0
+	for _, p := range ns.NotePoints() {
+		sum += p
+	}
+	return int(math.Round(sum))
+}
+
+// scoreWeight returns the relative weight of n for scoring.
+func (n Note) scoreWeight() Beat {
+	if !n.Type.IsValid() || n.Type.IsLineBreak() || n.Type.IsFreestyle() || n.Duration <= 0 {
+		return 0
+	}
+	if n.Type.IsGolden() {
+		return 2 * n.Duration
+	}
+	return n.Duration
+}
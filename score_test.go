@@ -0,0 +1,47 @@
+package ultrastar
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNotes_NotePoints(t *testing.T) {
+	ns := Notes{
+		{NoteTypeRegular, 0, 3, 0, "Some"},
+		{NoteTypeGolden, 4, 2, 0, "bo"},
+		{NoteTypeLineBreak, 7, 0, 0, "\n"},
+		{NoteTypeFreestyle, 8, 4, 0, "dy"},
+		{NoteTypeRap, 12, 1, 0, "once"},
+		{NoteTypeGoldenRap, 14, 1, 0, "told"},
+	}
+	actual := ns.NotePoints()
+	expected := []float64{3000, 4000, 0, 0, 1000, 2000}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ns.NotePoints() = %v, expected %v", actual, expected)
+	}
+	if points := ns.MaxPoints(); points != MaxScore {
+		t.Errorf("ns.MaxPoints() = %d, expected %d", points, MaxScore)
+	}
+}
+
+func TestNotes_MaxPoints(t *testing.T) {
+	cases := map[string]struct {
+		notes    Notes
+		expected int
+	}{
+		"uneven split": {Notes{
+			{NoteTypeRegular, 0, 1, 0, "Some"},
+			{NoteTypeRegular, 1, 1, 0, "bo"},
+			{NoteTypeRegular, 2, 1, 0, "dy"},
+		}, MaxScore},
+		"only freestyle": {Notes{{NoteTypeFreestyle, 0, 4, 0, "Some"}}, 0},
+		"empty":          {Notes{}, 0},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			if actual := c.notes.MaxPoints(); actual != c.expected {
+				t.Errorf("ns.MaxPoints() = %d, expected %d", actual, c.expected)
+			}
+		})
+	}
+}
@@ -1,9 +1,33 @@
 package ultrastar
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"math"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// These are the file extensions that are considered plausible by [Song.MediaWarnings].
+// Extensions are compared case-insensitively and include the leading dot.
+// You can modify these values to allow additional formats.
+var (
+	// AudioExtensions are the extensions of audio files.
+	AudioExtensions = []string{".mp3", ".ogg", ".opus", ".m4a", ".aac", ".wav", ".flac", ".wma"}
+	// VideoExtensions are the extensions of video files.
+	// Video files are also accepted as audio files.
+	VideoExtensions = []string{".mp4", ".m4v", ".mkv", ".webm", ".avi", ".mov", ".mpg", ".mpeg", ".flv", ".wmv"}
+	// ImageExtensions are the extensions of image files.
+	ImageExtensions = []string{".jpg", ".jpeg", ".png", ".gif", ".bmp", ".webp"}
+)
+
 // A Song is an implementation of an UltraStar song.
 // This implementation directly supports many of the known fields for songs,
 // making it convenient to work with.
@@ -19,7 +43,18 @@ type Song struct {
 	CoverFileName      string
 	BackgroundFileName string
 
+	// Web pages of the song's media.
+	// These do not reference files but can be used to find the respective media online.
+	AudioURL        *url.URL
+	VideoURL        *url.URL
+	CoverURL        *url.URL
+	BackgroundURL   *url.URL
+	VocalsURL       *url.URL
+	InstrumentalURL *url.URL
+
 	// The BPM of the song.
+	// Note that this is the actual BPM of the song's beats,
+	// which is 4 times as high as the #BPM value in UltraStar TXT files.
 	BPM BPM
 	// A delay until Beat 0 of the song's notes.
 	Gap time.Duration
@@ -70,6 +105,43 @@ func (s *Song) IsDuet() bool {
 	return s.NotesP2 != nil
 }
 
+// AudioIsVideo indicates whether the audio and video of s are taken from the same file.
+// This is the case if s.AudioFileName and s.VideoFileName are equal and not empty.
+func (s *Song) AudioIsVideo() bool {
+	return s.AudioFileName != "" && s.AudioFileName == s.VideoFileName
+}
+
+// MediaWarnings checks whether the file names of s have plausible extensions.
+// The audio file must be an audio or video file, the video file must be a video file
+// and the cover and background files must be images.
+// See [AudioExtensions], [VideoExtensions] and [ImageExtensions] for the known extensions.
+// Empty file names are not checked.
+//
+// The result contains a human-readable warning for each implausible file name.
+// This method does not access the file system.
+func (s *Song) MediaWarnings() []string {
+	var warnings []string
+	check := func(kind string, name string, extensions ...[]string) {
+		if name == "" {
+			return
+		}
+		ext := strings.ToLower(path.Ext(name))
+		for _, exts := range extensions {
+			for _, e := range exts {
+				if ext == e {
+					return
+				}
+			}
+		}
+		warnings = append(warnings, kind+" file "+strconv.Quote(name)+" has an unexpected extension")
+	}
+	check("audio", s.AudioFileName, AudioExtensions, VideoExtensions)
+	check("video", s.VideoFileName, VideoExtensions)
+	check("cover", s.CoverFileName, ImageExtensions)
+	check("background", s.BackgroundFileName, ImageExtensions)
+	return warnings
+}
+
 // Duration calculates the singing duration of s.
 // The singing duration is the time from the beginning of the song until the last sung note.
 func (s *Song) Duration() time.Duration {
@@ -87,4 +159,644 @@ func (s *Song) Duration() time.Duration {
 	return d
 }
 
+// PlayableWindow returns the part of the song that is played by UltraStar.
+// start is s.Start, end is s.End if it is set or [Song.Duration] otherwise.
+// If neither s.Start nor s.End is set, the window is the entire song and ok is false.
+// ok is also false if the window is empty or if the end cannot be determined because of an invalid BPM.
+func (s *Song) PlayableWindow() (start, end time.Duration, ok bool) {
+	if s.Start <= 0 && s.End <= 0 {
+		return 0, 0, false
+	}
+	start, end = s.Start, s.End
+	if end <= 0 {
+		if !s.BPM.IsValid() {
+			return 0, 0, false
+		}
+		end = s.Duration()
+	}
+	return start, end, end > start
+}
+
+// FirstSungBeat returns the start beat of the first note of s across all players.
+// Any note other than a line break counts, including rap and freestyle notes
+// (in contrast to [NoteType.IsSung]).
+// The notes of s do not need to be sorted.
+// If s does not contain any notes, false is returned as the second value.
+//
+// This can be useful to skip long intros by setting s.Start.
+func (s *Song) FirstSungBeat() (Beat, bool) {
+	first := MaxBeat
+	found := false
+	for _, ns := range []Notes{s.NotesP1, s.NotesP2} {
+		for _, n := range ns {
+			if !n.Type.IsLineBreak() && n.Start < first {
+				first = n.Start
+				found = true
+			}
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	return first, true
+}
+
+// ClickTrack calculates the times of a click track for s.
+// Clicks are placed every subdivision beats, starting at [Song.FirstSungBeat]
+// up to and including the [Notes.LastBeat] of all players.
+// The times include s.Gap.
+//
+// If s has no notes, an invalid BPM or subdivision is not positive, the result is nil.
+func (s *Song) ClickTrack(subdivision Beat) []time.Duration {
+	first, ok := s.FirstSungBeat()
+	if !ok || subdivision <= 0 || !s.BPM.IsValid() {
+		return nil
+	}
+	last := s.NotesP1.LastBeat()
+	if l := s.NotesP2.LastBeat(); l > last {
+		last = l
+	}
+	clicks := make([]time.Duration, 0, (last-first)/subdivision+1)
+	for beat := first; beat <= last; beat += subdivision {
+		clicks = append(clicks, s.Gap+s.BPM.Duration(beat))
+	}
+	return clicks
+}
+
+// SingingRatio calculates the fraction of s.Duration() during which at least one player has a note active.
+// All notes except line breaks are considered, including rap and freestyle notes.
+// Overlapping notes (e.g. in duets) are only counted once.
+//
+// The result is a value between 0 and 1.
+// If s has an invalid BPM or a duration of 0, the ratio is 0.
+func (s *Song) SingingRatio() float64 {
+	d := s.Duration()
+	if !s.BPM.IsValid() || d <= 0 {
+		return 0
+	}
+	var ns Notes
+	for _, voice := range []Notes{s.NotesP1, s.NotesP2} {
+		for _, n := range voice {
+			if !n.Type.IsLineBreak() && n.Duration > 0 {
+				ns = append(ns, n)
+			}
+		}
+	}
+	sort.Sort(ns)
+	var active Beat
+	var end Beat
+	for i, n := range ns {
+		start := n.Start
+		if i > 0 && start < end {
+			start = end
+		}
+		if nEnd := n.Start + n.Duration; nEnd > start {
+			active += nEnd - start
+			end = nEnd
+		}
+	}
+	return float64(s.BPM.Duration(active)) / float64(d)
+}
+
+// DetectPreviewStart returns the time at which a preview of s should start.
+// If s.PreviewStart is set it is returned unchanged.
+// Otherwise, if s has a medley section, the preview starts at the beginning of the medley.
+// If neither is available the preview starts at 25% of the song's [Song.Duration].
+//
+// The result of this method is undefined if s.BPM is invalid.
+func (s *Song) DetectPreviewStart() time.Duration {
+	if s.PreviewStart != 0 {
+		return s.PreviewStart
+	}
+	if s.MedleyEndBeat > s.MedleyStartBeat {
+		return s.Gap + s.BPM.Duration(s.MedleyStartBeat)
+	}
+	return s.Duration() / 4
+}
+
+// MedleyMinDuration is the minimum duration of a medley section as determined by [Song.CalculateMedley].
+const MedleyMinDuration = 40 * time.Second
+
+// CalculateMedley determines a medley section of s similar to the automatic medley calculation of UltraStar.
+// The medley section consists of complete lines of s.NotesP1 and is at least [MedleyMinDuration] long.
+// Of all candidate sections the one with the most scorable notes is chosen,
+// ties are resolved in favor of the earlier section.
+// The result can be assigned to s.MedleyStartBeat and s.MedleyEndBeat.
+//
+// CalculateMedley does not consider s.NoAutoMedley or existing medley beats.
+// If the BPM of s is invalid or s is too short for a medley, ok is false.
+func (s *Song) CalculateMedley() (start, end Beat, ok bool) {
+	if !s.BPM.IsValid() {
+		return 0, 0, false
+	}
+	type line struct {
+		start, end Beat
+		notes      int
+	}
+	var lines []line
+	s.NotesP1.EnumerateLines(func(ns []Note, _ Beat) {
+		if len(ns) == 0 {
+			return
+		}
+		l := line{start: ns[0].Start}
+		for _, n := range ns {
+			if n.End() > l.end {
+				l.end = n.End()
+			}
+			if n.scoreWeight() > 0 {
+				l.notes++
+			}
+		}
+		lines = append(lines, l)
+	})
+	best := -1
+	for i := range lines {
+		count := 0
+		for j := i; j < len(lines); j++ {
+			count += lines[j].notes
+			if s.BPM.Duration(lines[j].end-lines[i].start) >= MedleyMinDuration {
+				if count > best {
+					start, end, best = lines[i].start, lines[j].end, count
+				}
+				break
+			}
+		}
+	}
+	return start, end, best >= 0
+}
+
+// OnStrongBeat indicates whether note starts on the first beat of a bar.
+// Bars start at beat 0 and are beatsPerBar beats long.
+// Use [DefaultBeatsPerBar] or [Notes.EstimateBeatsPerBar] if the meter of s is unknown.
+// If beatsPerBar is not positive, the result is false.
+func (s *Song) OnStrongBeat(note Note, beatsPerBar Beat) bool {
+	return beatsPerBar > 0 && note.Start%beatsPerBar == 0
+}
+
+// RequantizeTo changes the BPM of s to targetBPM while preserving the absolute timing of the song.
+// The notes of all players and the medley beats are rescaled using [Notes.ScaleBPM].
+// Values are rounded to an integer using [ScaleRounding], so a higher target BPM results in a finer grid.
+//
+// The result of this method is undefined if s.BPM or targetBPM is invalid.
+func (s *Song) RequantizeTo(targetBPM BPM) {
+	s.NotesP1.ScaleBPM(s.BPM, targetBPM)
+	s.NotesP2.ScaleBPM(s.BPM, targetBPM)
+	factor := float64(targetBPM / s.BPM)
+	s.MedleyStartBeat = Beat(ScaleRounding(float64(s.MedleyStartBeat) * factor))
+	s.MedleyEndBeat = Beat(ScaleRounding(float64(s.MedleyEndBeat) * factor))
+	s.BPM = targetBPM
+}
+
+// EnumerateLines calls f for each line of the lyrics of all players in chronological order.
+// Lines are determined by [Notes.EnumerateLines] and ordered by the start of their first note.
+// Lines without notes are ordered by the start beat of their line break.
+// Lines starting at the same beat are ordered by player.
+//
+// The player index (0 for s.NotesP1, 1 for s.NotesP2) is passed to f as the first parameter.
+// The second and third parameters are the same as for [Notes.EnumerateLines].
+func (s *Song) EnumerateLines(f func(int, []Note, Beat)) {
+	type line struct {
+		player int
+		notes  []Note
+		end    Beat
+	}
+	var lines []line
+	for p, ns := range []Notes{s.NotesP1, s.NotesP2} {
+		ns.EnumerateLines(func(notes []Note, end Beat) {
+			lines = append(lines, line{p, notes, end})
+		})
+	}
+	start := func(l line) Beat {
+		if len(l.notes) == 0 {
+			return l.end
+		}
+		return l.notes[0].Start
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return start(lines[i]) < start(lines[j])
+	})
+	for _, l := range lines {
+		f(l.player, l.notes, l.end)
+	}
+}
+
+// ShortNotes finds notes whose duration is shorter than minDuration, using the BPM of s.
+// Very short notes are often transcription errors.
+// Line breaks are not considered.
+//
+// Each result is a pair of the player index (0 for s.NotesP1, 1 for s.NotesP2)
+// and the index of the note within the notes of that player.
+func (s *Song) ShortNotes(minDuration time.Duration) [][2]int {
+	var res [][2]int
+	for p, ns := range []Notes{s.NotesP1, s.NotesP2} {
+		for i, n := range ns {
+			if !n.Type.IsLineBreak() && s.BPM.Duration(n.Duration) < minDuration {
+				res = append(res, [2]int{p, i})
+			}
+		}
+	}
+	return res
+}
+
+// Lyrics generates the full lyrics of s.
+// For non-duet songs this is the same as s.NotesP1.Lyrics().
+// For duets the lyrics of both players are concatenated, separated by a blank line.
+// The lyrics of each player are preceded by a line containing the name of the player.
+// If no name is set for a player, "P1" or "P2" is used instead.
+func (s *Song) Lyrics() string {
+	if !s.IsDuet() {
+		return s.NotesP1.Lyrics()
+	}
+	var b strings.Builder
+	for i, p := range []struct {
+		name  string
+		notes Notes
+	}{{s.DuetSinger1, s.NotesP1}, {s.DuetSinger2, s.NotesP2}} {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+		if p.name == "" {
+			p.name = "P" + strconv.Itoa(i+1)
+		}
+		b.WriteString(p.name)
+		b.WriteString("\n")
+		b.WriteString(p.notes.Lyrics())
+	}
+	return b.String()
+}
+
+// TransposeWithKey changes the pitch of all notes in s by delta semitones (see [Notes.Transpose]).
+// Additionally, if s has a custom "KEY" tag with a value of a pitch including its octave (e.g. "C#4"),
+// the value of that tag is transposed as well.
+// The transposed key is always written using EnglishNoteNames.
+// Values of the "KEY" tag that cannot be parsed are left untouched.
+func (s *Song) TransposeWithKey(delta Pitch) {
+	s.NotesP1.Transpose(delta)
+	s.NotesP2.Transpose(delta)
+
+	value, ok := s.CustomTags["KEY"]
+	if !ok {
+		return
+	}
+	key, err := PitchFromString(value)
+	if err != nil {
+		return
+	}
+	// PitchFromString falls back to octave 4 if no octave is given.
+	// We only accept keys that explicitly specify an octave to avoid losing information (e.g. "Am").
+	if _, err = strconv.Atoi(strings.TrimLeft(value[1:], "#b")); err != nil {
+		return
+	}
+	key += delta
+	class := int(key) % len(EnglishNoteNames)
+	if class < 0 {
+		class += len(EnglishNoteNames)
+	}
+	s.CustomTags["KEY"] = EnglishNoteNames[class] + strconv.Itoa(key.Octave())
+}
+
+// maxTransposition is the largest shift in semitones considered by [Song.BestTransposition].
+const maxTransposition = 12
+
+// BestTransposition finds the shift in semitones that minimizes the number of
+// sung notes (as determined by [NoteType.IsSung]) of s with a pitch outside of [lo, hi].
+// Shifts of up to one octave in either direction are considered.
+// If multiple shifts are equally good, the one with the smallest absolute value is returned.
+// Of two shifts with the same absolute value the upward shift is preferred.
+//
+// The result can be passed to [Song.TransposeWithKey]. s is not modified.
+func (s *Song) BestTransposition(lo, hi Pitch) Pitch {
+	var best Pitch
+	bestCount := -1
+	for i := 0; i <= 2*maxTransposition; i++ {
+		// 0, 1, -1, 2, -2, ...
+		delta := Pitch((i + 1) / 2)
+		if i%2 == 0 {
+			delta = -delta
+		}
+		count := 0
+		for _, ns := range [2]Notes{s.NotesP1, s.NotesP2} {
+			for _, n := range ns {
+				if n.Type.IsSung() && (n.Pitch+delta < lo || n.Pitch+delta > hi) {
+					count++
+				}
+			}
+		}
+		if bestCount < 0 || count < bestCount {
+			best, bestCount = delta, count
+		}
+	}
+	return best
+}
+
+// IsTranspositionOf reports whether other is a transposition of s.
+// This is the case if both songs have the same notes except for a constant pitch difference.
+// Notes must match in type, timing and text.
+// The pitch is only compared for sung notes (as determined by [NoteType.IsSung]).
+//
+// If other is a transposition of s, the difference in semitones from s to other is returned.
+// If there are no sung notes, the difference is 0.
+func (s *Song) IsTranspositionOf(other *Song) (Pitch, bool) {
+	if s.IsDuet() != other.IsDuet() {
+		return 0, false
+	}
+	var delta Pitch
+	found := false
+	for i, ns := range [2]Notes{s.NotesP1, s.NotesP2} {
+		others := other.NotesP1
+		if i == 1 {
+			others = other.NotesP2
+		}
+		if len(ns) != len(others) {
+			return 0, false
+		}
+		for j, n := range ns {
+			o := others[j]
+			if n.Type != o.Type || n.Start != o.Start || n.Duration != o.Duration || n.Text != o.Text {
+				return 0, false
+			}
+			if !n.Type.IsSung() {
+				continue
+			}
+			if !found {
+				delta, found = o.Pitch-n.Pitch, true
+			} else if o.Pitch-n.Pitch != delta {
+				return 0, false
+			}
+		}
+	}
+	return delta, true
+}
+
+// Normalize performs a number of cleanup operations on s.
+// This is intended as a one-stop cleanup for songs from untrusted sources.
+// The following steps are performed in order:
+//
+//  1. Custom tags with an empty value are removed.
+//     For UltraStar an empty tag is equivalent to an absent tag.
+//  2. If s.NotesP2 is empty the song is converted into a non-duet song.
+//  3. The notes of all players are sorted by their start beat.
+//     Notes with the same start beat keep their relative order.
+//  4. Redundant line breaks are removed via [Notes.NormalizeLineBreaks].
+//  5. The BPM of the song is validated.
+//     An invalid BPM is not modified but reported via ErrInvalidBPM.
+//
+// All steps are performed even if the BPM of s is invalid.
+func (s *Song) Normalize() error {
+	for tag, value := range s.CustomTags {
+		if value == "" {
+			delete(s.CustomTags, tag)
+		}
+	}
+	if len(s.NotesP2) == 0 {
+		s.NotesP2 = nil
+	}
+	sort.Stable(s.NotesP1)
+	s.NotesP1.NormalizeLineBreaks()
+	if s.IsDuet() {
+		sort.Stable(s.NotesP2)
+		s.NotesP2.NormalizeLineBreaks()
+	}
+	if !s.BPM.IsValid() {
+		return ErrInvalidBPM
+	}
+	return nil
+}
+
+// CompactVoices removes players from s that have no notes and no name.
+// If player 1 is removed, player 2 takes its place, including its duet singer name.
+// If only a single player remains, s is converted into a non-duet song.
+// Note that this changes the P-numbers of the remaining players.
+//
+// The result maps the old player indexes (0 for s.NotesP1, 1 for s.NotesP2) to the new indexes.
+// Removed players are mapped to -1.
+// The result always has a length of 2.
+func (s *Song) CompactVoices() []int {
+	if !s.IsDuet() {
+		return []int{0, -1}
+	}
+	mapping := []int{0, 1}
+	if len(s.NotesP2) == 0 && s.DuetSinger2 == "" {
+		s.NotesP2 = nil
+		mapping[1] = -1
+	}
+	if s.IsDuet() && len(s.NotesP1) == 0 && s.DuetSinger1 == "" {
+		s.NotesP1, s.NotesP2 = s.NotesP2, nil
+		s.DuetSinger1, s.DuetSinger2 = s.DuetSinger2, ""
+		mapping[0], mapping[1] = -1, 0
+	}
+	return mapping
+}
+
+// ContentHash calculates a SHA-256 hash over the contents of s and returns it as a hex string.
+// The hash is calculated over a canonical encoding of s (see [Song.GobEncode])
+// in which custom tags are sorted by name and notes are sorted by their start beat.
+// Songs that only differ in the order of their custom tags or notes have the same hash.
+// s itself is not modified.
+func (s *Song) ContentHash() string {
+	c := *s
+	for _, ns := range []*Notes{&c.NotesP1, &c.NotesP2} {
+		if *ns != nil {
+			sorted := append(Notes{}, *ns...)
+			sort.Stable(sorted)
+			*ns = sorted
+		}
+	}
+	// Song.GobEncode does not return an error.
+	bs, _ := c.GobEncode()
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
+}
+
+// GobEncode encodes s into a byte slice.
+func (s *Song) GobEncode() ([]byte, error) {
+	var bs []byte
+	for _, v := range []string{s.AudioFileName, s.VideoFileName, s.CoverFileName, s.BackgroundFileName} {
+		bs = appendString(bs, v)
+	}
+	for _, u := range []*url.URL{s.AudioURL, s.VideoURL, s.CoverURL, s.BackgroundURL, s.VocalsURL, s.InstrumentalURL} {
+		if u == nil {
+			bs = appendString(bs, "")
+		} else {
+			bs = appendString(bs, u.String())
+		}
+	}
+	bs = binary.BigEndian.AppendUint64(bs, math.Float64bits(float64(s.BPM)))
+	for _, d := range []time.Duration{s.Gap, s.VideoGap, s.Start, s.End, s.PreviewStart} {
+		bs = binary.AppendVarint(bs, int64(d))
+	}
+	bs = binary.AppendVarint(bs, int64(s.MedleyStartBeat))
+	bs = binary.AppendVarint(bs, int64(s.MedleyEndBeat))
+	if s.NoAutoMedley {
+		bs = append(bs, 1)
+	} else {
+		bs = append(bs, 0)
+	}
+	for _, v := range []string{s.Title, s.Artist, s.Genre, s.Edition, s.Creator, s.Language} {
+		bs = appendString(bs, v)
+	}
+	bs = binary.AppendVarint(bs, int64(s.Year))
+	for _, v := range []string{s.Comment, s.DuetSinger1, s.DuetSinger2} {
+		bs = appendString(bs, v)
+	}
+
+	// Custom tags are sorted to produce a deterministic encoding.
+	tags := make([]string, 0, len(s.CustomTags))
+	for tag := range s.CustomTags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	bs = binary.AppendUvarint(bs, uint64(len(tags)))
+	for _, tag := range tags {
+		bs = appendString(bs, tag)
+		bs = appendString(bs, s.CustomTags[tag])
+	}
+
+	for _, ns := range []Notes{s.NotesP1, s.NotesP2} {
+		// nil notes are encoded as a single 0 byte.
+		if ns == nil {
+			bs = append(bs, 0)
+			continue
+		}
+		nbs, err := ns.GobEncode()
+		if err != nil {
+			return nil, err
+		}
+		bs = append(bs, 1)
+		bs = appendBytes(bs, nbs)
+	}
+	return bs, nil
+}
+
+// GobDecode updates s from the encoded byte slice.
+func (s *Song) GobDecode(bs []byte) error {
+	r := bytes.NewReader(bs)
+	var err error
+	for _, v := range []*string{&s.AudioFileName, &s.VideoFileName, &s.CoverFileName, &s.BackgroundFileName} {
+		if *v, err = readString(r); err != nil {
+			return err
+		}
+	}
+	for _, u := range []**url.URL{&s.AudioURL, &s.VideoURL, &s.CoverURL, &s.BackgroundURL, &s.VocalsURL, &s.InstrumentalURL} {
+		v, err := readString(r)
+		if err != nil {
+			return err
+		}
+		*u = nil
+		if v != "" {
+			if *u, err = url.Parse(v); err != nil {
+				return err
+			}
+		}
+	}
+	var bpm [8]byte
+	if _, err = io.ReadFull(r, bpm[:]); err != nil {
+		return err
+	}
+	s.BPM = BPM(math.Float64frombits(binary.BigEndian.Uint64(bpm[:])))
+	for _, d := range []*time.Duration{&s.Gap, &s.VideoGap, &s.Start, &s.End, &s.PreviewStart} {
+		if v, err := binary.ReadVarint(r); err != nil {
+			return err
+		} else {
+			*d = time.Duration(v)
+		}
+	}
+	for _, b := range []*Beat{&s.MedleyStartBeat, &s.MedleyEndBeat} {
+		if v, err := binary.ReadVarint(r); err != nil {
+			return err
+		} else {
+			*b = Beat(v)
+		}
+	}
+	if b, err := r.ReadByte(); err != nil {
+		return err
+	} else {
+		s.NoAutoMedley = b != 0
+	}
+	for _, v := range []*string{&s.Title, &s.Artist, &s.Genre, &s.Edition, &s.Creator, &s.Language} {
+		if *v, err = readString(r); err != nil {
+			return err
+		}
+	}
+	if y, err := binary.ReadVarint(r); err != nil {
+		return err
+	} else {
+		s.Year = int(y)
+	}
+	for _, v := range []*string{&s.Comment, &s.DuetSinger1, &s.DuetSinger2} {
+		if *v, err = readString(r); err != nil {
+			return err
+		}
+	}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	s.CustomTags = nil
+	if count > 0 {
+		s.CustomTags = make(map[string]string, count)
+	}
+	for i := uint64(0); i < count; i++ {
+		tag, err := readString(r)
+		if err != nil {
+			return err
+		}
+		if s.CustomTags[tag], err = readString(r); err != nil {
+			return err
+		}
+	}
+
+	for _, ns := range []*Notes{&s.NotesP1, &s.NotesP2} {
+		*ns = nil
+		if b, err := r.ReadByte(); err != nil {
+			return err
+		} else if b == 0 {
+			continue
+		}
+		nbs, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		if err = ns.GobDecode(nbs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendBytes appends v to bs, prefixed by its length.
+func appendBytes(bs []byte, v []byte) []byte {
+	bs = binary.AppendUvarint(bs, uint64(len(v)))
+	return append(bs, v...)
+}
+
+// appendString appends v to bs, prefixed by its length.
+func appendString(bs []byte, v string) []byte {
+	bs = binary.AppendUvarint(bs, uint64(len(v)))
+	return append(bs, v...)
+}
+
+// readBytes reads a length-prefixed byte slice from r.
+// This is the inverse of appendBytes.
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if l > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	bs := make([]byte, l)
+	_, err = io.ReadFull(r, bs)
+	return bs, err
+}
+
+// readString reads a length-prefixed string from r.
+// This is the inverse of appendString.
+func readString(r *bytes.Reader) (string, error) {
+	bs, err := readBytes(r)
+	return string(bs), err
+}
+
 // TODO: Function to minimize or maximize the Gap
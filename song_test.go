@@ -0,0 +1,617 @@
+package ultrastar
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSong_Normalize(t *testing.T) {
+	s := &Song{
+		BPM: 0,
+		CustomTags: map[string]string{
+			"EMPTY":  "",
+			"CUSTOM": "value",
+		},
+		NotesP1: Notes{
+			{NoteTypeLineBreak, 0, 0, 0, "\n"},
+			{NoteTypeRegular, 10, 2, 0, "body"},
+			{NoteTypeRegular, 4, 2, 0, "Some"},
+			{NoteTypeLineBreak, 12, 0, 0, "\n"},
+			{NoteTypeLineBreak, 13, 0, 0, "\n"},
+			{NoteTypeRegular, 16, 2, 0, "once"},
+			{NoteTypeLineBreak, 20, 0, 0, "\n"},
+		},
+		NotesP2: Notes{},
+	}
+	err := s.Normalize()
+	if !errors.Is(err, ErrInvalidBPM) {
+		t.Errorf("s.Normalize() = %v, expected ErrInvalidBPM", err)
+	}
+	if s.BPM != 0 {
+		t.Errorf("s.Normalize() changed s.BPM to %f, expected 0", s.BPM)
+	}
+	if _, ok := s.CustomTags["EMPTY"]; ok {
+		t.Errorf("s.Normalize() did not remove the empty custom tag")
+	}
+	if s.CustomTags["CUSTOM"] != "value" {
+		t.Errorf("s.CustomTags[%q] = %q, expected %q", "CUSTOM", s.CustomTags["CUSTOM"], "value")
+	}
+	if s.IsDuet() {
+		t.Errorf("s.IsDuet() = true, expected false")
+	}
+	expected := Notes{
+		{NoteTypeRegular, 4, 2, 0, "Some"},
+		{NoteTypeRegular, 10, 2, 0, "body"},
+		{NoteTypeLineBreak, 12, 0, 0, "\n"},
+		{NoteTypeRegular, 16, 2, 0, "once"},
+	}
+	if len(s.NotesP1) != len(expected) {
+		t.Fatalf("len(s.NotesP1) = %d, expected %d", len(s.NotesP1), len(expected))
+	}
+	for i := range expected {
+		if s.NotesP1[i] != expected[i] {
+			t.Errorf("s.NotesP1[%d] = %v, expected %v", i, s.NotesP1[i], expected[i])
+		}
+	}
+
+	s.BPM = 120
+	if err = s.Normalize(); err != nil {
+		t.Errorf("s.Normalize() caused an unexpected error: %s", err)
+	}
+}
+
+func TestSong_AudioIsVideo(t *testing.T) {
+	cases := map[string]struct {
+		audio    string
+		video    string
+		expected bool
+	}{
+		"same file":      {"clip.mp4", "clip.mp4", true},
+		"different file": {"audio.mp3", "clip.mp4", false},
+		"no files":       {"", "", false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &Song{AudioFileName: c.audio, VideoFileName: c.video}
+			if actual := s.AudioIsVideo(); actual != c.expected {
+				t.Errorf("s.AudioIsVideo() = %t, expected %t", actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestSong_MediaWarnings(t *testing.T) {
+	s := &Song{
+		AudioFileName:      "clip.MP4",
+		VideoFileName:      "clip.mp4",
+		CoverFileName:      "cover.mp3",
+		BackgroundFileName: "",
+	}
+	actual := s.MediaWarnings()
+	expected := []string{`cover file "cover.mp3" has an unexpected extension`}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("s.MediaWarnings() = %q, expected %q", actual, expected)
+	}
+}
+
+func TestSong_GobEncode(t *testing.T) {
+	cases := map[string]Song{
+		"empty song": {},
+		"full song": {
+			AudioFileName:      "audio.mp3",
+			VideoFileName:      "video.mp4",
+			CoverFileName:      "cover.jpg",
+			BackgroundFileName: "background.jpg",
+			AudioURL:           &url.URL{Scheme: "https", Host: "example.com", Path: "/audio"},
+			BPM:                1248.64,
+			Gap:                37480 * time.Millisecond,
+			VideoGap:           -2 * time.Second,
+			Start:              5 * time.Second,
+			End:                3 * time.Minute,
+			PreviewStart:       42 * time.Second,
+			MedleyStartBeat:    674,
+			MedleyEndBeat:      1306,
+			NoAutoMedley:       true,
+			Title:              "All Star",
+			Artist:             "Smash Mouth",
+			Genre:              "Rock",
+			Edition:            "None",
+			Creator:            "Canni",
+			Language:           "English",
+			Year:               1999,
+			Comment:            "Some comment",
+			DuetSinger1:        "Steve",
+			DuetSinger2:        "Greg",
+			CustomTags:         map[string]string{"FOO": "bar", "ABC": ""},
+			NotesP1: Notes{
+				{NoteTypeRegular, 0, 6, 6, "Some"},
+				{NoteTypeLineBreak, 8, 0, 0, "\n"},
+				{NoteTypeGolden, 12, 4, 13, "bo"},
+			},
+			NotesP2: Notes{
+				{NoteTypeFreestyle, 18, 3, -10, "dy "},
+			},
+		},
+		"empty duet": {
+			BPM:     120,
+			NotesP1: Notes{},
+			NotesP2: Notes{},
+		},
+	}
+	for name, song := range cases {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			if err := gob.NewEncoder(buf).Encode(&song); err != nil {
+				t.Fatalf("GobEncode() caused an unexpected error: %s", err)
+			}
+			var s Song
+			if err := gob.NewDecoder(buf).Decode(&s); err != nil {
+				t.Fatalf("GobDecode() caused an unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(s, song) {
+				t.Errorf("GobDecode(GobEncode(s)) = %v, expected %v", s, song)
+			}
+		})
+	}
+}
+
+func TestSong_CompactVoices(t *testing.T) {
+	notes := Notes{{NoteTypeRegular, 0, 2, 0, "Some"}}
+	cases := map[string]struct {
+		song     Song
+		expected []int
+		duet     bool
+	}{
+		"empty P2":       {Song{NotesP1: notes, NotesP2: Notes{}}, []int{0, -1}, false},
+		"empty P1":       {Song{NotesP1: Notes{}, NotesP2: notes, DuetSinger2: "Greg"}, []int{-1, 0}, false},
+		"named empty P2": {Song{NotesP1: notes, NotesP2: Notes{}, DuetSinger2: "Greg"}, []int{0, 1}, true},
+		"duet":           {Song{NotesP1: notes, NotesP2: notes}, []int{0, 1}, true},
+		"single player":  {Song{NotesP1: notes}, []int{0, -1}, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := c.song.CompactVoices()
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("s.CompactVoices() = %v, expected %v", actual, c.expected)
+			}
+			if c.song.IsDuet() != c.duet {
+				t.Errorf("s.IsDuet() = %t, expected %t", c.song.IsDuet(), c.duet)
+			}
+			if !c.song.NotesP1.Equal(notes) {
+				t.Errorf("s.NotesP1 = %v, expected %v", c.song.NotesP1, notes)
+			}
+		})
+	}
+}
+
+func TestSong_ContentHash(t *testing.T) {
+	a := &Song{
+		Title:      "All Star",
+		BPM:        480,
+		CustomTags: map[string]string{},
+		NotesP1: Notes{
+			{NoteTypeRegular, 0, 2, 0, "Some"},
+			{NoteTypeRegular, 2, 2, 0, "body"},
+		},
+	}
+	b := &Song{
+		Title:      "All Star",
+		BPM:        480,
+		CustomTags: map[string]string{},
+		NotesP1: Notes{
+			{NoteTypeRegular, 2, 2, 0, "body"},
+			{NoteTypeRegular, 0, 2, 0, "Some"},
+		},
+	}
+	for _, tag := range []string{"FOO", "BAR", "BAZ"} {
+		a.CustomTags[tag] = tag
+	}
+	for _, tag := range []string{"BAZ", "FOO", "BAR"} {
+		b.CustomTags[tag] = tag
+	}
+	if a.ContentHash() != b.ContentHash() {
+		t.Errorf("a.ContentHash() = %s, b.ContentHash() = %s, expected them to be equal", a.ContentHash(), b.ContentHash())
+	}
+	if b.NotesP1[0].Text != "body" {
+		t.Errorf("b.ContentHash() modified b.NotesP1")
+	}
+	b.Title = "Another Star"
+	if a.ContentHash() == b.ContentHash() {
+		t.Errorf("a.ContentHash() = b.ContentHash() = %s, expected them to be different", a.ContentHash())
+	}
+}
+
+func TestSong_Lyrics(t *testing.T) {
+	s := &Song{
+		DuetSinger1: "Steve",
+		NotesP1: Notes{
+			{NoteTypeRegular, 0, 2, 0, "Some"},
+			{NoteTypeRegular, 2, 2, 0, "body"},
+			{NoteTypeLineBreak, 5, 0, 0, "\n"},
+			{NoteTypeRegular, 6, 2, 0, "once"},
+		},
+		NotesP2: Notes{
+			{NoteTypeRegular, 8, 2, 0, "told"},
+			{NoteTypeRegular, 10, 2, 0, " me"},
+		},
+	}
+	expected := "Steve\nSomebody\nonce\n\nP2\ntold me"
+	actual := s.Lyrics()
+	if actual != expected {
+		t.Errorf("s.Lyrics() = %q, expected %q", actual, expected)
+	}
+}
+
+func TestSong_TransposeWithKey(t *testing.T) {
+	cases := map[string]struct {
+		key      string
+		expected string
+	}{
+		"C4":          {"C4", "D4"},
+		"A#3":         {"A#3", "C4"},
+		"minor key":   {"Am", "Am"},
+		"invalid key": {"foo", "foo"},
+	}
+	downCases := map[string]struct {
+		key      string
+		delta    Pitch
+		expected string
+	}{
+		"C4 down an octave": {"C4", -12, "C3"},
+		"D3 to C3":          {"D3", -2, "C3"},
+		"C3 to B2":          {"C3", -1, "B2"},
+		"C3 unchanged":      {"C3", 0, "C3"},
+	}
+	for name, c := range downCases {
+		t.Run(name, func(t *testing.T) {
+			s := &Song{CustomTags: map[string]string{"KEY": c.key}}
+			s.TransposeWithKey(c.delta)
+			if s.CustomTags["KEY"] != c.expected {
+				t.Errorf("s.TransposeWithKey(%d) resulted in %q, expected %q", c.delta, s.CustomTags["KEY"], c.expected)
+			}
+		})
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &Song{
+				CustomTags: map[string]string{"KEY": c.key},
+				NotesP1: Notes{
+					{NoteTypeRegular, 0, 2, 0, "Some"},
+					{NoteTypeLineBreak, 4, 0, 0, "\n"},
+					{NoteTypeGolden, 6, 2, -3, "body"},
+				},
+			}
+			s.TransposeWithKey(2)
+			if s.CustomTags["KEY"] != c.expected {
+				t.Errorf("s.CustomTags[%q] = %q, expected %q", "KEY", s.CustomTags["KEY"], c.expected)
+			}
+			if s.NotesP1[0].Pitch != 2 || s.NotesP1[1].Pitch != 0 || s.NotesP1[2].Pitch != -1 {
+				t.Errorf("s.TransposeWithKey(2) resulted in %v, expected pitches 2, 0, -1", s.NotesP1)
+			}
+		})
+	}
+}
+
+func TestSong_BestTransposition(t *testing.T) {
+	cases := map[string]struct {
+		notes    Notes
+		lo, hi   Pitch
+		expected Pitch
+	}{
+		"in range": {Notes{
+			{NoteTypeRegular, 0, 2, 2, "Some"},
+			{NoteTypeRegular, 4, 2, 5, "body"},
+		}, 0, 7, 0},
+		"too low": {Notes{
+			{NoteTypeRegular, 0, 2, -2, "Some"},
+			{NoteTypeGolden, 4, 2, 0, "bo"},
+			{NoteTypeRegular, 6, 2, 5, "dy"},
+			{NoteTypeFreestyle, 8, 2, 20, "once"},
+		}, 0, 7, 2},
+		"too high": {Notes{
+			{NoteTypeRegular, 0, 2, 10, "Some"},
+			{NoteTypeRegular, 4, 2, 3, "body"},
+		}, 0, 7, -3},
+		"tie": {Notes{
+			{NoteTypeRegular, 0, 2, -1, "Some"},
+			{NoteTypeRegular, 4, 2, 1, "body"},
+		}, 0, 0, 1},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := &Song{NotesP1: c.notes}
+			if actual := s.BestTransposition(c.lo, c.hi); actual != c.expected {
+				t.Errorf("s.BestTransposition(%d, %d) = %d, expected %d", c.lo, c.hi, actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestSong_IsTranspositionOf(t *testing.T) {
+	s := &Song{NotesP1: Notes{
+		{NoteTypeRegular, 0, 2, 0, "Some"},
+		{NoteTypeRap, 2, 2, 4, "bo"},
+		{NoteTypeLineBreak, 5, 0, 0, "\n"},
+		{NoteTypeGolden, 6, 2, -3, "dy"},
+	}}
+	t.Run("transposed", func(t *testing.T) {
+		other := &Song{NotesP1: append(Notes(nil), s.NotesP1...)}
+		other.NotesP1.Transpose(3)
+		other.NotesP1[1].Pitch = 12
+		delta, ok := s.IsTranspositionOf(other)
+		if !ok || delta != 3 {
+			t.Errorf("s.IsTranspositionOf(other) = %d, %t, expected 3, true", delta, ok)
+		}
+	})
+	t.Run("different pitches", func(t *testing.T) {
+		other := &Song{NotesP1: append(Notes(nil), s.NotesP1...)}
+		other.NotesP1[0].Pitch = 3
+		other.NotesP1[3].Pitch = 2
+		if delta, ok := s.IsTranspositionOf(other); ok {
+			t.Errorf("s.IsTranspositionOf(other) = %d, %t, expected false", delta, ok)
+		}
+	})
+	t.Run("different text", func(t *testing.T) {
+		other := &Song{NotesP1: append(Notes(nil), s.NotesP1...)}
+		other.NotesP1[0].Text = "Any"
+		if delta, ok := s.IsTranspositionOf(other); ok {
+			t.Errorf("s.IsTranspositionOf(other) = %d, %t, expected false", delta, ok)
+		}
+	})
+}
+
+func TestSong_CalculateMedley(t *testing.T) {
+	// 4 beats per second, so a medley needs at least 160 beats.
+	s := &Song{BPM: 240}
+	for l := 0; l < 10; l++ {
+		count := 1
+		if l >= 4 {
+			count = 4
+		}
+		for j := 0; j < count; j++ {
+			s.NotesP1 = append(s.NotesP1, Note{NoteTypeRegular, Beat(32*l + 2*j), 1, 0, "la"})
+		}
+		s.NotesP1 = append(s.NotesP1, Note{NoteTypeLineBreak, Beat(32*l + 30), 0, 0, "\n"})
+	}
+
+	start, end, ok := s.CalculateMedley()
+	if !ok || start != 128 || end != 295 {
+		t.Errorf("s.CalculateMedley() = %d, %d, %t, expected 128, 295, true", start, end, ok)
+	}
+
+	s.NotesP1 = s.NotesP1[:10]
+	if start, end, ok = s.CalculateMedley(); ok {
+		t.Errorf("s.CalculateMedley() = %d, %d, %t for a short song, expected false", start, end, ok)
+	}
+}
+
+func TestSong_OnStrongBeat(t *testing.T) {
+	cases := map[string]struct {
+		start       Beat
+		beatsPerBar Beat
+		expected    bool
+	}{
+		"beat 0":        {0, 16, true},
+		"beat 4":        {4, 16, false},
+		"beat 32":       {32, 16, true},
+		"negative":      {-16, 16, true},
+		"invalid meter": {0, 0, false},
+	}
+	s := &Song{}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			n := Note{NoteTypeRegular, c.start, 2, 0, "Some"}
+			if actual := s.OnStrongBeat(n, c.beatsPerBar); actual != c.expected {
+				t.Errorf("s.OnStrongBeat(%v, %d) = %t, expected %t", n, c.beatsPerBar, actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestSong_PlayableWindow(t *testing.T) {
+	notes := Notes{
+		{NoteTypeRegular, 0, 4, 0, "Some"},
+		{NoteTypeRegular, 56, 4, 0, "body"},
+	}
+	cases := map[string]struct {
+		start, end    time.Duration
+		expectedStart time.Duration
+		expectedEnd   time.Duration
+		expectedOK    bool
+	}{
+		"start and end": {10 * time.Second, 20 * time.Second, 10 * time.Second, 20 * time.Second, true},
+		"only start":    {5 * time.Second, 0, 5 * time.Second, 16 * time.Second, true},
+		"only end":      {0, 12 * time.Second, 0, 12 * time.Second, true},
+		"none":          {0, 0, 0, 0, false},
+		"empty":         {20 * time.Second, 10 * time.Second, 20 * time.Second, 10 * time.Second, false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			// 240 beats per minute are 4 beats per second, so the notes last 15 seconds.
+			s := &Song{BPM: 240, Gap: time.Second, Start: c.start, End: c.end, NotesP1: notes}
+			start, end, ok := s.PlayableWindow()
+			if start != c.expectedStart || end != c.expectedEnd || ok != c.expectedOK {
+				t.Errorf("s.PlayableWindow() = %s, %s, %t, expected %s, %s, %t", start, end, ok, c.expectedStart, c.expectedEnd, c.expectedOK)
+			}
+		})
+	}
+}
+
+func TestSong_ShortNotes(t *testing.T) {
+	s := &Song{
+		BPM: 1200,
+		NotesP1: Notes{
+			{NoteTypeRegular, 0, 4, 0, "Some"},
+			{NoteTypeLineBreak, 5, 0, 0, "\n"},
+			{NoteTypeRegular, 6, 1, 0, "bo"},
+		},
+		NotesP2: Notes{
+			{NoteTypeRegular, 8, 1, 0, "dy"},
+		},
+	}
+	// At 1200 BPM a single beat takes 50ms.
+	actual := s.ShortNotes(100 * time.Millisecond)
+	expected := [][2]int{{0, 2}, {1, 0}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("s.ShortNotes(100ms) = %v, expected %v", actual, expected)
+	}
+}
+
+func TestSong_FirstSungBeat(t *testing.T) {
+	s := &Song{
+		NotesP1: Notes{
+			{NoteTypeLineBreak, 10, 0, 0, "\n"},
+			{NoteTypeRegular, 32, 2, 0, "Some"},
+		},
+		NotesP2: Notes{
+			{NoteTypeRegular, 30, 2, 0, "body"},
+		},
+	}
+	beat, ok := s.FirstSungBeat()
+	if !ok || beat != 30 {
+		t.Errorf("s.FirstSungBeat() = %d, %t, expected 30, true", beat, ok)
+	}
+
+	s = &Song{NotesP1: Notes{
+		{NoteTypeRegular, 32, 2, 0, "Some"},
+		{NoteTypeFreestyle, 20, 2, 0, "body"},
+	}}
+	beat, ok = s.FirstSungBeat()
+	if !ok || beat != 20 {
+		t.Errorf("s.FirstSungBeat() = %d, %t for unsorted notes, expected 20, true", beat, ok)
+	}
+
+	s = &Song{NotesP1: Notes{{NoteTypeLineBreak, 10, 0, 0, "\n"}}}
+	if _, ok = s.FirstSungBeat(); ok {
+		t.Errorf("s.FirstSungBeat() returned true for a song without notes, expected false")
+	}
+}
+
+func TestSong_ClickTrack(t *testing.T) {
+	s := &Song{
+		BPM: 120,
+		Gap: time.Second,
+		NotesP1: Notes{
+			{NoteTypeRegular, 2, 4, 0, "Some"},
+			{NoteTypeLineBreak, 7, 0, 0, "\n"},
+			{NoteTypeRegular, 8, 3, 0, "body"},
+		},
+	}
+	actual := s.ClickTrack(4)
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 6 * time.Second}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("s.ClickTrack(4) = %v, expected %v", actual, expected)
+	}
+	if actual = s.ClickTrack(0); actual != nil {
+		t.Errorf("s.ClickTrack(0) = %v, expected nil", actual)
+	}
+}
+
+func TestSong_SingingRatio(t *testing.T) {
+	s := &Song{
+		BPM: 60,
+		Gap: 10 * time.Second,
+		NotesP1: Notes{
+			{NoteTypeRegular, 0, 4, 0, "Some"},
+			{NoteTypeLineBreak, 5, 0, 0, "\n"},
+			{NoteTypeFreestyle, 20, 2, 0, "body"},
+		},
+		NotesP2: Notes{
+			{NoteTypeRegular, 2, 4, 0, "once"},
+			{NoteTypeRap, 28, 2, 0, "told"},
+		},
+	}
+	// The song is 40 seconds long, notes are active for 10 seconds.
+	if actual := s.SingingRatio(); actual != 0.25 {
+		t.Errorf("s.SingingRatio() = %f, expected %f", actual, 0.25)
+	}
+}
+
+func TestSong_DetectPreviewStart(t *testing.T) {
+	cases := map[string]struct {
+		song     Song
+		expected time.Duration
+	}{
+		"explicit preview": {Song{BPM: 60, PreviewStart: 3 * time.Second, MedleyStartBeat: 10, MedleyEndBeat: 20}, 3 * time.Second},
+		"medley":           {Song{BPM: 60, Gap: time.Second, MedleyStartBeat: 10, MedleyEndBeat: 20}, 11 * time.Second},
+		"no medley": {Song{BPM: 60, NotesP1: Notes{
+			{NoteTypeRegular, 0, 20, 0, "Some"},
+			{NoteTypeRegular, 20, 20, 0, "body"},
+		}}, 10 * time.Second},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := c.song.DetectPreviewStart()
+			if actual != c.expected {
+				t.Errorf("s.DetectPreviewStart() = %s, expected %s", actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestSong_RequantizeTo(t *testing.T) {
+	s := &Song{
+		BPM:             120,
+		MedleyStartBeat: 4,
+		MedleyEndBeat:   10,
+		NotesP1: Notes{
+			{NoteTypeRegular, 0, 2, 0, "Some"},
+			{NoteTypeLineBreak, 3, 0, 0, "\n"},
+			{NoteTypeRegular, 4, 6, 0, "body"},
+		},
+		NotesP2: Notes{
+			{NoteTypeRegular, 2, 3, 0, "once"},
+		},
+	}
+	d1, d2 := s.NotesP1.Duration(s.BPM), s.NotesP2.Duration(s.BPM)
+	s.RequantizeTo(480)
+	if s.BPM != 480 {
+		t.Errorf("s.RequantizeTo(480) changed s.BPM to %f, expected 480", s.BPM)
+	}
+	if d := s.NotesP1.Duration(s.BPM); d != d1 {
+		t.Errorf("s.NotesP1.Duration() = %s, expected %s", d, d1)
+	}
+	if d := s.NotesP2.Duration(s.BPM); d != d2 {
+		t.Errorf("s.NotesP2.Duration() = %s, expected %s", d, d2)
+	}
+	if s.MedleyStartBeat != 16 || s.MedleyEndBeat != 40 {
+		t.Errorf("s.RequantizeTo(480) changed medley to %d-%d, expected 16-40", s.MedleyStartBeat, s.MedleyEndBeat)
+	}
+	if s.NotesP2[0].Start != 8 || s.NotesP2[0].Duration != 12 {
+		t.Errorf("s.NotesP2[0] = %v, expected start 8 and duration 12", s.NotesP2[0])
+	}
+}
+
+func TestSong_EnumerateLines(t *testing.T) {
+	s := &Song{
+		NotesP1: Notes{
+			{NoteTypeRegular, 0, 2, 0, "Some"},
+			{NoteTypeLineBreak, 3, 0, 0, "\n"},
+			{NoteTypeRegular, 10, 2, 0, "once"},
+		},
+		NotesP2: Notes{
+			{NoteTypeRegular, 4, 2, 0, "body"},
+			{NoteTypeLineBreak, 7, 0, 0, "\n"},
+			{NoteTypeRegular, 14, 2, 0, "told"},
+		},
+	}
+	var players []int
+	var texts []string
+	s.EnumerateLines(func(player int, line []Note, _ Beat) {
+		players = append(players, player)
+		texts = append(texts, Notes(line).Lyrics())
+	})
+	expectedPlayers := []int{0, 1, 0, 1}
+	expectedTexts := []string{"Some", "body", "once", "told"}
+	if !reflect.DeepEqual(players, expectedPlayers) {
+		t.Errorf("s.EnumerateLines() enumerated players %v, expected %v", players, expectedPlayers)
+	}
+	if !reflect.DeepEqual(texts, expectedTexts) {
+		t.Errorf("s.EnumerateLines() enumerated lines %v, expected %v", texts, expectedTexts)
+	}
+}
@@ -0,0 +1,32 @@
+package ultrastar
+
+import (
+	"strconv"
+	"time"
+)
+
+// NewTestSong creates a minimal valid song that can be used as a fixture in tests.
+// The song has a single player with the specified number of evenly spaced regular notes.
+// Each note has a duration of 2 beats and notes start 4 beats apart.
+// The song has a BPM of 120 and a gap of 1 second.
+//
+// The returned song is a new value on each call, so it can be modified freely.
+func NewTestSong(notes int) *Song {
+	s := &Song{
+		Title:  "Test Song",
+		Artist: "Test Artist",
+		BPM:    120,
+		Gap:    time.Second,
+	}
+	s.NotesP1 = make(Notes, notes)
+	for i := range s.NotesP1 {
+		s.NotesP1[i] = Note{
+			Type:     NoteTypeRegular,
+			Start:    Beat(i * 4),
+			Duration: 2,
+			Pitch:    Pitch(i % 12),
+			Text:     "la" + strconv.Itoa(i) + " ",
+		}
+	}
+	return s
+}
@@ -0,0 +1,27 @@
+package ultrastar
+
+import "testing"
+
+func TestNewTestSong(t *testing.T) {
+	s := NewTestSong(10)
+	if len(s.NotesP1) != 10 {
+		t.Errorf("len(s.NotesP1) = %d, expected 10", len(s.NotesP1))
+	}
+	if s.IsDuet() {
+		t.Errorf("s.IsDuet() = true, expected false")
+	}
+	if !s.BPM.IsValid() {
+		t.Errorf("s.BPM.IsValid() = false, expected true")
+	}
+	if !s.NotesP1.IsSorted() {
+		t.Errorf("s.NotesP1.IsSorted() = false, expected true")
+	}
+	for i := 1; i < len(s.NotesP1); i++ {
+		if s.NotesP1[i-1].Start+s.NotesP1[i-1].Duration > s.NotesP1[i].Start {
+			t.Errorf("s.NotesP1[%d] overlaps with s.NotesP1[%d]", i-1, i)
+		}
+	}
+	if err := s.Normalize(); err != nil {
+		t.Errorf("s.Normalize() caused an unexpected error: %s", err)
+	}
+}
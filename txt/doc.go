@@ -12,9 +12,10 @@
 // It is your responsibility to detect the encoding of the source data and convert it appropriately.
 //
 // There are some songs that make use of a special ENCODING tag.
-// This package explicitly does not support this tag.
-// If you want to process the tag you can either do some pre-processing or
-// re-encode strings after they have been parsed.
+// If [Reader.ApplyEncoding] is set, the reader decodes songs with an ENCODING tag of
+// CP1250, CP1251, CP1252 or ISO-8859-2.
+// If you need other encodings you can either do some pre-processing or
+// re-encode strings after they have been parsed (see [TransformSong]).
 //
 // There are UltraStar TXTs known that use a UTF-8 byte order mark (BOM).
 // The parser in this package is able to understand UTF-8 and UTF-16 BOMs with no further configuration.
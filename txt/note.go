@@ -35,20 +35,51 @@ func ParseNote(s string) (ultrastar.Note, error) {
 // If an error occurs the returned note may be partially initialized. However,
 // this behavior should not be relied upon.
 func ParseNoteRelative(s string, relative bool) (ultrastar.Note, error) {
-	return parseNoteRelative(s, relative, true)
+	return parseNoteRelative(s, noteOptions{relative: relative, strict: true})
+}
+
+// FormatNote converts n into an UltraStar-style note line without a trailing newline.
+// This is the inverse of [ParseNoteRelative] and uses the same format as [Writer.WriteNote].
+// Fields are separated by sep, which should be a space or a tab.
+// If relative is true, line breaks are written in the relative format "- A A".
+func FormatNote(n ultrastar.Note, sep rune, relative bool) string {
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.FieldSeparator = sep
+	w.Relative = relative
+	// Writing to a strings.Builder does not fail.
+	_ = w.WriteNote(n)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// noteOptions configure the behavior of parseNoteRelative.
+type noteOptions struct {
+	// relative indicates whether line breaks use the relative format.
+	relative bool
+	// strict indicates that line breaks cannot have extra text after them.
+	strict bool
+	// spaceOnly indicates that only spaces are accepted as field separators.
+	spaceOnly bool
+	// emptyFreestyleText indicates that freestyle notes may have an empty text.
+	emptyFreestyleText bool
+	// anyType indicates that unknown note types are accepted and parsed like regular notes.
+	anyType bool
 }
 
 // parseNoteRelative implements the [ParseNoteRelative] function.
-// The parsing behavior can be configured via a strict parameter that controls
-// if line breaks can have extra text after them.
-func parseNoteRelative(s string, relative bool, strict bool) (ultrastar.Note, error) {
+// The parsing behavior can be configured via opts.
+func parseNoteRelative(s string, opts noteOptions) (ultrastar.Note, error) {
+	seps := " \t"
+	if opts.spaceOnly {
+		seps = " "
+	}
 	n := ultrastar.Note{}
 	if s == "" {
 		return n, errors.New("invalid note type")
 	}
 	nType := ultrastar.NoteType(s[0])
 	s = s[1:]
-	if !nType.IsValid() {
+	if !nType.IsValid() && !opts.anyType {
 		return n, fmt.Errorf("invalid note type: %c", nType)
 	}
 	n.Type = nType
@@ -56,28 +87,28 @@ func parseNoteRelative(s string, relative bool, strict bool) (ultrastar.Note, er
 		n.Text = "\n"
 	}
 
-	value, s := nextField(s)
+	value, s := nextField(s, seps)
 	start, err := strconv.Atoi(value)
 	n.Start = ultrastar.Beat(start)
 	if err != nil {
 		return n, fmt.Errorf("invalid note start: %wr", err)
 	}
 
-	if nType.IsLineBreak() && !relative {
-		if strict && strings.TrimSpace(s) != "" {
+	if nType.IsLineBreak() && !opts.relative {
+		if opts.strict && strings.TrimSpace(s) != "" {
 			return n, fmt.Errorf("invalid line break: extra text")
 		}
 		return n, nil
 	}
 
-	value, s = nextField(s)
+	value, s = nextField(s, seps)
 	duration, err := strconv.Atoi(value)
 	n.Duration = ultrastar.Beat(duration)
 	if n.Type.IsLineBreak() {
 		if err != nil {
 			return n, fmt.Errorf("invalid line break: invalid relative spec: %wr", err)
 		}
-		if strict && strings.TrimSpace(s) != "" {
+		if opts.strict && strings.TrimSpace(s) != "" {
 			return n, fmt.Errorf("invalid line break: extra text")
 		}
 		return n, nil
@@ -86,17 +117,20 @@ func parseNoteRelative(s string, relative bool, strict bool) (ultrastar.Note, er
 		return n, fmt.Errorf("invalid note duration: %wr", err)
 	}
 
-	value, s = nextField(s)
+	value, s = nextField(s, seps)
 	pitch, err := strconv.Atoi(value)
 	n.Pitch = ultrastar.Pitch(pitch)
 	if err != nil {
 		return n, fmt.Errorf("invalid note pitch: %wr", err)
 	}
 
+	if opts.emptyFreestyleText && n.Type.IsFreestyle() && len(s) <= 1 && strings.Trim(s, seps) == "" {
+		return n, nil
+	}
 	if s == "" {
 		return n, errors.New("empty note text")
 	}
-	if s[0] != ' ' && s[0] != '\t' {
+	if strings.IndexByte(seps, s[0]) < 0 {
 		return n, errors.New("missing whitespace after note pitch")
 	}
 	if len(s) < 2 {
@@ -106,19 +140,20 @@ func parseNoteRelative(s string, relative bool, strict bool) (ultrastar.Note, er
 	return n, nil
 }
 
-// nextField finds the next whitespace-separated field in a string. The function
-// skips over leading whitespace and finds a consecutive run of non-space and
-// non-tab characters. Returned is the found field and the remaining string.
-func nextField(s string) (string, string) {
+// nextField finds the next separated field in a string. The function skips over
+// leading separators and finds a consecutive run of non-separator characters.
+// Valid separator characters are given by seps. Returned is the found field and
+// the remaining string.
+func nextField(s string, seps string) (string, string) {
 	start := 0
 	for ; start < len(s); start++ {
-		if s[start] != ' ' && s[start] != '\t' {
+		if strings.IndexByte(seps, s[start]) < 0 {
 			break
 		}
 	}
 	end := start
 	for ; end < len(s); end++ {
-		if s[end] == ' ' || s[end] == '\t' {
+		if strings.IndexByte(seps, s[end]) >= 0 {
 			break
 		}
 	}
@@ -41,3 +41,35 @@ func TestParseNote(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatNote(t *testing.T) {
+	cases := map[string]struct {
+		note     ultrastar.Note
+		sep      rune
+		relative bool
+		expected string
+	}{
+		"regular note":        {ultrastar.Note{Type: ultrastar.NoteTypeRegular, Start: 5, Duration: 2, Pitch: 3, Text: " some"}, ' ', false, ": 5 2 3  some"},
+		"tab separator":       {ultrastar.Note{Type: ultrastar.NoteTypeGolden, Start: 5, Duration: 2, Pitch: -3, Text: "some"}, '\t', false, "*\t5\t2\t-3\tsome"},
+		"line break":          {ultrastar.Note{Type: ultrastar.NoteTypeLineBreak, Start: 12, Text: "\n"}, ' ', false, "- 12"},
+		"relative line break": {ultrastar.Note{Type: ultrastar.NoteTypeLineBreak, Start: 12, Text: "\n"}, ' ', true, "- 12 12"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := FormatNote(c.note, c.sep, c.relative)
+			if actual != c.expected {
+				t.Errorf("FormatNote(%v) = %q, expected %q", c.note, actual, c.expected)
+			}
+			if c.relative {
+				return
+			}
+			n, err := ParseNote(actual)
+			if err != nil {
+				t.Fatalf("ParseNote(%q) caused an unexpected error: %s", actual, err)
+			}
+			if n != c.note {
+				t.Errorf("ParseNote(%q) = %v, expected %v", actual, n, c.note)
+			}
+		})
+	}
+}
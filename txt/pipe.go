@@ -0,0 +1,67 @@
+package txt
+
+import (
+	"io"
+
+	"golang.org/x/text/transform"
+
+	"codello.dev/ultrastar"
+)
+
+// Pipe reads a single song from r, applies f to each of its notes and writes the result to w.
+// f is called with a pointer to the note and the index of the player (0 for P1, 1 for P2).
+// f may modify the note in place.
+// If f returns false, the note is removed from the song.
+//
+// The tags of the song are preserved as closely as possible:
+// The original values of the #BPM and medley tags (see [Reader.PreserveBPMString]),
+// blank tag lines (see [Reader.PreserveBlankTags]) and the #ENCODING of the song are kept.
+// Note texts passed to f are decoded to UTF-8 and encoded again when the song is written.
+// If a modified text cannot be represented in the encoding of the song, an error is returned.
+// If the input is in relative mode, the output is written in relative mode as well.
+//
+// The reader has no API to read a single note at a time,
+// so the whole song is held in memory during the transformation.
+// If an error occurs during reading, nothing is written to w.
+func Pipe(r io.Reader, w io.Writer, f func(*ultrastar.Note, int) bool) error {
+	rd := NewReader(r)
+	rd.PreserveBPMString = true
+	rd.PreserveMedleyStrings = true
+	rd.PreserveBlankTags = true
+	s, err := rd.ReadSong()
+	if err != nil {
+		return err
+	}
+	for p, ns := range []*ultrastar.Notes{&s.NotesP1, &s.NotesP2} {
+		if *ns == nil {
+			continue
+		}
+		res := (*ns)[:0]
+		for _, n := range *ns {
+			if f(&n, p) {
+				res = append(res, n)
+			}
+		}
+		*ns = res
+	}
+
+	// ReadSong succeeded, so the encoding is known.
+	enc, _ := encodingByName(rd.Encoding)
+	if enc == nil {
+		return pipeWriter(w, rd).WriteSong(s)
+	}
+	tw := transform.NewWriter(w, enc.NewEncoder())
+	if err = pipeWriter(tw, rd).WriteSong(s); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// pipeWriter returns a Writer for w that reproduces the format detected by rd.
+func pipeWriter(w io.Writer, rd *Reader) *Writer {
+	wr := NewWriter(w)
+	wr.Relative = rd.Relative
+	wr.BlankTags = rd.BlankTags
+	wr.Encoding = rd.Encoding
+	return wr
+}
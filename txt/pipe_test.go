@@ -0,0 +1,47 @@
+package txt
+
+import (
+	"strings"
+	"testing"
+
+	"codello.dev/ultrastar"
+)
+
+func TestPipe(t *testing.T) {
+	input := "#TITLE:Foo\n#BPM:12\n: 1 2 0 Some\n- 4\nF 5 2 3 body\n: 8 2 -1 once\nE\n"
+	b := &strings.Builder{}
+	err := Pipe(strings.NewReader(input), b, func(n *ultrastar.Note, player int) bool {
+		if n.Type.IsFreestyle() {
+			return false
+		}
+		if !n.Type.IsLineBreak() {
+			n.Pitch += 2
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Pipe() caused an unexpected error: %s", err)
+	}
+	expected := "#TITLE:Foo\n#BPM:12\n: 1 2 2 Some\n- 4\n: 8 2 1 once\nE\n"
+	if b.String() != expected {
+		t.Errorf("Pipe() resulted in %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestPipe_PreserveTags(t *testing.T) {
+	input := "#TITLE:Caf\xe9\n#\n#ENCODING:CP1252\n#BPM:199,96\n#MEDLEYSTARTBEAT:08\n#MEDLEYENDBEAT:016\n: 1 2 0 S\xe9\n: 3 2 0 body\nE\n"
+	b := &strings.Builder{}
+	err := Pipe(strings.NewReader(input), b, func(n *ultrastar.Note, player int) bool {
+		if n.Text == "body" {
+			n.Text = "b\u00f6dy"
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Pipe() caused an unexpected error: %s", err)
+	}
+	expected := "#TITLE:Caf\xe9\n#\n#MEDLEYSTARTBEAT:08\n#MEDLEYENDBEAT:016\n#BPM:199,96\n#ENCODING:CP1252\n: 1 2 0 S\xe9\n: 3 2 0 b\xf6dy\nE\n"
+	if b.String() != expected {
+		t.Errorf("Pipe() resulted in %q, expected %q", b.String(), expected)
+	}
+}
@@ -2,6 +2,8 @@ package txt
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
@@ -41,6 +43,16 @@ var (
 	ErrUnknownEvent = errors.New("invalid event")
 	// ErrUnknownEncoding indicates that the value of the #ENCODING tag was not understood.
 	ErrUnknownEncoding = errors.New("unknown encoding")
+	// ErrLineTooLong indicates that a line exceeds the limit set by [Reader.MaxLineBytes]
+	// or the default limit of [bufio.Scanner].
+	ErrLineTooLong = errors.New("line too long")
+	// ErrBackwardsJump indicates that a note in a relative song starts before the previous note.
+	// This usually means that a song mixes relative and absolute sections.
+	// This is only reported as a warning.
+	ErrBackwardsJump = errors.New("note starts before previous note")
+	// ErrSwappedColumns indicates that the duration and pitch of a note seem to be swapped.
+	// This is only reported as a warning if [Reader.AutoFixColumnSwap] is set.
+	ErrSwappedColumns = errors.New("duration and pitch columns swapped")
 )
 
 // ParseError is an error type that may be returned by the parsing methods.
@@ -73,11 +85,21 @@ func ParseSong(s string) (ultrastar.Song, error) {
 	return NewReader(strings.NewReader(s)).ReadSong()
 }
 
+// ReadAllSongs parses all songs from rd.
+// This is a convenience method for [Reader.ReadAllSongs].
+func ReadAllSongs(rd io.Reader) ([]ultrastar.Song, error) {
+	return NewReader(rd).ReadAllSongs()
+}
+
 // Reader implements the parser for the UltraStar TXT format.
 type Reader struct {
 	// AllowBOM controls whether the parser should support songs that have an explicit Byte Order Mark.
 	// If set to true the parser will understand and decode UTF-8 and UTF-16 BOMs.
 	AllowBOM bool
+	// AllowGzip controls whether the parser transparently decompresses gzip-compressed songs (such as .txt.gz files).
+	// If set to true and the input starts with the gzip magic number, the input is decompressed before parsing.
+	// Errors of the decompression (e.g. of a truncated stream) are returned unchanged when reading.
+	AllowGzip bool
 	// ApplyEncoding controls whether the #ENCODING tag interpreted and applied to the song.
 	// If it is not applied it will be treated as a custom tag.
 	// If the encoding contains a value the parser does not understand it custom tag will be present as well.
@@ -97,14 +119,100 @@ type Reader struct {
 	// StrictEndTag controls whether any line starting with 'E' counts as an end tag.
 	// If set to true only a single 'E' may be on the ending line.
 	StrictEndTag bool
+	// LenientEndTag controls whether any line whose first non-whitespace character is 'E' ends a song.
+	// If set to true lines such as "End" or " E" are accepted as end tags, regardless of r.StrictEndTag.
+	LenientEndTag bool
 	// AllowInternationalFloat controls whether floats can use a comma as the decimal separator.
 	AllowInternationalFloat bool
 	// IgnoreBPMChanges controls whether the parser silently ignores BPM change markers.
 	IgnoreBPMChanges bool
+	// BPMFromFirstChange controls whether a BPM change marker at beat 0 can replace a missing #BPM tag.
+	// If set to true and a song has no #BPM tag, a first line of the form "B 0 <bpm>" after the tags
+	// sets the BPM of the song.
+	// Other BPM change markers are still handled according to r.IgnoreBPMChanges.
+	BPMFromFirstChange bool
+	// SpaceSeparatedHeaders controls whether tags without a colon may use whitespace to separate tag and value.
+	// If set to true, a line like "#TITLE Some Title" is parsed as tag "TITLE" with value "Some Title".
+	// If set to false, such a line is parsed as a tag "TITLE SOME TITLE" with an empty value.
+	SpaceSeparatedHeaders bool
+	// TrimTagValues controls whether leading and trailing whitespace is removed from tag values.
+	// Numeric values are always trimmed.
+	// Set this to false if you need to preserve the exact values of tags.
+	TrimTagValues bool
+	// TolerateTrailingUnits controls whether numeric values of the #BPM, #GAP and #YEAR tags may
+	// have a trailing unit (such as "#BPM:120 bpm"). The unit is ignored.
+	TolerateTrailingUnits bool
+	// PreserveBlankTags controls whether the positions of blank tag lines (lines consisting only of '#') are recorded.
+	// Blank tag lines are always ignored when parsing a song.
+	// If set to true, their positions are recorded in r.BlankTags.
+	PreserveBlankTags bool
+	// PreserveBPMString controls whether the original value of the #BPM tag is stored in the custom tags of a song.
+	// If set to true, the value is stored in s.CustomTags[TagBPM] in addition to s.BPM.
+	// The [Writer] emits the stored value verbatim as long as it still corresponds to s.BPM.
+	// This avoids changes such as "199,96" being written as "199.96" or being altered by floating point rounding.
+	PreserveBPMString bool
+	// PreserveMedleyStrings controls whether the original values of the #MEDLEYSTARTBEAT and #MEDLEYENDBEAT tags
+	// are stored in the custom tags of a song.
+	// This works like r.PreserveBPMString and preserves formatting details such as leading zeros.
+	PreserveMedleyStrings bool
+	// RequireSpaceSeparator controls whether notes must use spaces as field separators.
+	// If set to true a note using tabs as field separators results in an error.
+	RequireSpaceSeparator bool
+	// AllowEmptyFreestyleText controls whether freestyle notes may have an empty text.
+	// UltraStar allows this, other note types always require a text.
+	AllowEmptyFreestyleText bool
+	// UnknownNotesAsFreestyle controls whether lines starting with an unknown character are parsed as freestyle notes.
+	// If set to true, such a line is parsed as a freestyle note and a warning is recorded (see [Reader.Warnings]).
+	// If set to false, an unknown character results in an ErrUnknownEvent.
+	UnknownNotesAsFreestyle bool
+	// StrictNoteTypes controls whether lines starting with an unknown character are rejected.
+	// If set to false, such a line is parsed like a regular note and the character is preserved as the note type.
+	// This allows unknown note types to survive a round trip through [Writer], which writes them unchanged.
+	// In this case r.UnknownNotesAsFreestyle has no effect.
+	//
+	// Unknown note types are neither sung nor rap, golden, freestyle or line break notes (see [ultrastar.NoteType]).
+	// They are not scored and are reported by [ultrastar.Song.Validate].
+	StrictNoteTypes bool
+	// AutoFixColumnSwap controls whether the parser tries to correct notes with swapped duration and pitch values.
+	// The heuristic is deliberately conservative:
+	// Only notes with a non-positive duration (which is never valid) and a positive pitch are corrected.
+	// For such notes the duration and pitch are swapped and a warning is recorded (see [Reader.Warnings]).
+	AutoFixColumnSwap bool
+	// UnescapeNoteText controls whether the escape sequence \n (a literal backslash followed by 'n')
+	// in note texts is replaced by r.NoteTextNewline.
+	// Some tools use this sequence to encode a newline within a note,
+	// which cannot be represented in the line-based TXT format.
+	UnescapeNoteText bool
+	// NoteTextNewline is the replacement for \n sequences in note texts if r.UnescapeNoteText is set.
+	NoteTextNewline string
+	// MaxNotes limits the number of notes (including line breaks) that are read from a song.
+	// When the limit is reached the parser stops and returns the notes read so far without an error.
+	// The remaining notes of the song are not consumed.
+	// A value of 0 means that the number of notes is unlimited.
+	MaxNotes int
+	// MaxLineBytes limits the length of a single line in bytes, not including the line terminator.
+	// If a longer line is encountered parsing fails with an ErrLineTooLong.
+	// This can be used to limit memory usage when parsing untrusted input.
+	// A value of 0 means that the default limit of [bufio.Scanner] applies.
+	MaxLineBytes int
+	// ApplyResolution controls whether the #RESOLUTION tag is applied to the notes of a song.
+	// If set to true and a song has a #RESOLUTION other than 4, all note and medley beats are scaled
+	// by 4/resolution (rounded using [ultrastar.ScaleRounding]) so that they use the standard grid.
+	// The #RESOLUTION tag is then removed from the song and its value is recorded in r.Resolution.
+	// Invalid resolution values are ignored.
+	ApplyResolution bool
 
 	// Relative indicates whether the parser is in relative mode.
 	// After parsing a song you can use this field to determine whether the song was originally in relative mode.
 	Relative bool
+	// BlankTags records the positions of blank tag lines if r.PreserveBlankTags is set.
+	// Each position is the number of tags preceding the blank line.
+	// Assign this value to [Writer.BlankTags] to re-emit the blank lines.
+	// This is reset every time tags are read.
+	BlankTags []int
+	// Resolution is the original resolution of a song if r.ApplyResolution is set and the notes have been scaled.
+	// Otherwise, this is 0.
+	Resolution int
 	// Encoding is the encoding used to decode textual data.
 	// During parsing this will be set to the appropriate header field of the song,
 	// unless it has been set explicitly.
@@ -116,6 +224,9 @@ type Reader struct {
 	line   string         // current line, set by scan
 	lineNo int            // current line number, set by scan
 	err    error          // last scanner error, set by scan
+
+	warnings  []error // non-fatal problems encountered during parsing
+	bytesRead int64   // number of bytes read from rd
 }
 
 // NewReader creates a new Reader instance reading from rd.
@@ -129,6 +240,7 @@ type Reader struct {
 func NewReader(rd io.Reader) *Reader {
 	r := &Reader{
 		AllowBOM:                true,
+		AllowGzip:               true,
 		ApplyEncoding:           true,
 		IgnoreEmptyLines:        true,
 		IgnoreLeadingSpaces:     false,
@@ -136,8 +248,26 @@ func NewReader(rd io.Reader) *Reader {
 		StrictLineBreaks:        true,
 		EndTagRequired:          false,
 		StrictEndTag:            true,
+		LenientEndTag:           false,
 		AllowInternationalFloat: true,
 		IgnoreBPMChanges:        false,
+		BPMFromFirstChange:      false,
+		SpaceSeparatedHeaders:   false,
+		TrimTagValues:           true,
+		TolerateTrailingUnits:   false,
+		PreserveBlankTags:       false,
+		PreserveBPMString:       false,
+		PreserveMedleyStrings:   false,
+		RequireSpaceSeparator:   false,
+		AllowEmptyFreestyleText: true,
+		UnknownNotesAsFreestyle: false,
+		StrictNoteTypes:         true,
+		AutoFixColumnSwap:       false,
+		UnescapeNoteText:        false,
+		NoteTextNewline:         " ",
+		MaxNotes:                0,
+		MaxLineBytes:            0,
+		ApplyResolution:         false,
 	}
 	r.Reset(rd)
 	return r
@@ -146,6 +276,7 @@ func NewReader(rd io.Reader) *Reader {
 // UseUltraStarDialect configures r to match the behavior of the UltraStar TXT parser as closely as possible.
 func (r *Reader) UseUltraStarDialect() {
 	r.AllowBOM = true
+	r.AllowGzip = false
 	r.ApplyEncoding = true
 	r.IgnoreEmptyLines = false
 	r.IgnoreLeadingSpaces = false
@@ -153,12 +284,26 @@ func (r *Reader) UseUltraStarDialect() {
 	r.StrictLineBreaks = false
 	r.EndTagRequired = false
 	r.StrictEndTag = false
+	r.LenientEndTag = false
 	r.AllowInternationalFloat = true
 	r.IgnoreBPMChanges = true
+	r.BPMFromFirstChange = false
+	r.SpaceSeparatedHeaders = false
+	r.TrimTagValues = true
+	r.TolerateTrailingUnits = false
+	r.PreserveBlankTags = false
+	r.PreserveBPMString = false
+	r.PreserveMedleyStrings = false
+	r.RequireSpaceSeparator = false
+	r.AllowEmptyFreestyleText = true
+	r.UnknownNotesAsFreestyle = false
+	r.StrictNoteTypes = true
+	r.AutoFixColumnSwap = false
+	r.UnescapeNoteText = false
 }
 
 // Reset configures r to read from r, just like NewReader(rd) would.
-// r keeps its configuration, however r.Relative and r.Encoding are reset.
+// r keeps its configuration, however r.Relative, r.Encoding, r.BlankTags, the warnings of r and r.BytesRead() are reset.
 //
 // Note that because Reader sometimes reads ahead, r.Reset(r.rd) may produce unexpected results.
 func (r *Reader) Reset(rd io.Reader) {
@@ -170,17 +315,96 @@ func (r *Reader) Reset(rd io.Reader) {
 	r.err = nil
 
 	r.Relative = false
+	r.Resolution = 0
 	r.Encoding = ""
+	r.BlankTags = nil
+	r.warnings = nil
+	r.bytesRead = 0
+}
+
+// Warnings returns the non-fatal problems that were encountered during parsing.
+// Each warning is a ParseError identifying the line of the problem.
+// Warnings are only recorded for lenient parsing options.
+func (r *Reader) Warnings() []error {
+	return r.warnings
+}
+
+// warn records a warning for the current line.
+func (r *Reader) warn(err error) {
+	r.warnings = append(r.warnings, ParseError{r.lineNo, err})
+}
+
+// BytesRead returns the number of bytes that have been read from the underlying reader.
+// Because r reads ahead, this may be more than the number of bytes that have been parsed.
+// After a song has been read completely, this is usually the size of the input.
+// For compressed input (see r.AllowGzip) the compressed bytes are counted.
+// The value is reset by [Reader.Reset].
+func (r *Reader) BytesRead() int64 {
+	return r.bytesRead
+}
+
+// countingReader is an io.Reader that counts the number of bytes read from rd.
+type countingReader struct {
+	rd io.Reader
+	n  *int64
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.rd.Read(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// errorReader is an io.Reader that always returns err.
+type errorReader struct {
+	err error
+}
+
+// Read implements io.Reader.
+func (e errorReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+// gzipMagic is the header of a gzip-compressed stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// decompress wraps r.rd in a gzip.Reader if it starts with the gzip magic number.
+// Otherwise, r.rd produces the same data as before.
+func (r *Reader) decompress() {
+	br := bufio.NewReader(r.rd)
+	r.rd = br
+	if magic, _ := br.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return
+	}
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		r.rd = errorReader{err}
+		return
+	}
+	r.rd = gz
 }
 
 // setupScanner configures r.s.
 // This must be called before any read operation is performed.
 func (r *Reader) setupScanner() {
 	if r.s == nil {
+		r.rd = &countingReader{r.rd, &r.bytesRead}
+		if r.AllowGzip {
+			r.decompress()
+		}
 		if r.AllowBOM {
 			r.rd = transform.NewReader(r.rd, unicode.BOMOverride(transform.Nop))
 		}
 		r.s = bufio.NewScanner(r.rd)
+		if r.MaxLineBytes > 0 {
+			// The buffer must be able to hold the line terminator as well.
+			size := r.MaxLineBytes + 2
+			if size > 4096 {
+				size = 4096
+			}
+			r.s.Buffer(make([]byte, 0, size), r.MaxLineBytes+2)
+		}
 	}
 }
 
@@ -203,6 +427,15 @@ func (r *Reader) scan() bool {
 	}
 	r.line = r.s.Text()
 	r.err = r.s.Err()
+	if errors.Is(r.err, bufio.ErrTooLong) || (r.MaxLineBytes > 0 && len(r.line) > r.MaxLineBytes) {
+		limit := r.MaxLineBytes
+		if limit <= 0 {
+			limit = bufio.MaxScanTokenSize
+		}
+		r.line = ""
+		r.err = fmt.Errorf("%w: more than %d bytes", ErrLineTooLong, limit)
+		return false
+	}
 	if r.IgnoreLeadingSpaces {
 		r.line = strings.TrimLeft(r.line, " \t")
 	}
@@ -253,10 +486,16 @@ func (r *Reader) ReadSong() (ultrastar.Song, error) {
 	if err = r.skipEmptyLines(); err != nil {
 		return song, ParseError{r.lineNo, r.err}
 	}
+	if r.BPMFromFirstChange && song.BPM == 0 {
+		r.readFirstBPMChange(&song)
+	}
 	song.NotesP1, song.NotesP2, err = r.readNotes(true)
 	if err != nil {
 		return song, ParseError{r.lineNo, err}
 	}
+	if r.ApplyResolution {
+		r.applyResolution(&song)
+	}
 	if !r.ApplyEncoding {
 		return song, nil
 	}
@@ -266,6 +505,83 @@ func (r *Reader) ReadSong() (ultrastar.Song, error) {
 	return song, nil
 }
 
+// readFirstBPMChange sets the BPM of s from the next line if it is a BPM change marker at beat 0.
+// If the next line is not such a marker, it is not consumed.
+func (r *Reader) readFirstBPMChange(s *ultrastar.Song) {
+	if !r.scan() {
+		return
+	}
+	if r.line == "" || r.line[0] != 'B' {
+		r.unscan()
+		return
+	}
+	beat, value := nextField(r.line[1:], " \t")
+	value = strings.TrimSpace(value)
+	bpm, err := parseFloat(value, r.AllowInternationalFloat)
+	if beat != "0" || err != nil || !ultrastar.BPM(bpm).IsValid() {
+		r.unscan()
+		return
+	}
+	s.BPM = ultrastar.BPM(bpm * 4)
+}
+
+// applyResolution scales the beats of s according to its #RESOLUTION tag.
+// See r.ApplyResolution for details.
+func (r *Reader) applyResolution(s *ultrastar.Song) {
+	res, err := strconv.Atoi(strings.TrimSpace(s.CustomTags[TagResolution]))
+	if err != nil || res <= 0 || res == 4 {
+		return
+	}
+	factor := 4 / float64(res)
+	s.NotesP1.Scale(factor)
+	s.NotesP2.Scale(factor)
+	s.MedleyStartBeat = ultrastar.Beat(ultrastar.ScaleRounding(float64(s.MedleyStartBeat) * factor))
+	s.MedleyEndBeat = ultrastar.Beat(ultrastar.ScaleRounding(float64(s.MedleyEndBeat) * factor))
+	delete(s.CustomTags, TagResolution)
+	r.Resolution = res
+}
+
+// ReadAllSongs parses a sequence of concatenated songs from r until the end of the input.
+// Each song must start with a tag line (a line starting with '#')
+// and all but the last song must end with an end tag (a line starting with 'E').
+// Any lines between the end tag of a song and the first tag line of the next song are ignored.
+//
+// The r.Relative and r.Encoding values are reset before each song is parsed,
+// so after this method returns they correspond to the last song.
+//
+// If an error occurs the songs that have been parsed successfully are returned together with the error.
+func (r *Reader) ReadAllSongs() ([]ultrastar.Song, error) {
+	r.setupScanner()
+	var songs []ultrastar.Song
+	for r.skipToTags() {
+		r.Relative = false
+		r.Resolution = 0
+		r.Encoding = ""
+		song, err := r.ReadSong()
+		if err != nil {
+			return songs, err
+		}
+		songs = append(songs, song)
+	}
+	if r.err != nil {
+		return songs, ParseError{r.lineNo, r.err}
+	}
+	return songs, nil
+}
+
+// skipToTags advances r to the next line starting with '#'.
+// The next call to r.scan will return that line.
+// If no such line is found, false is returned.
+func (r *Reader) skipToTags() bool {
+	for r.scan() {
+		if r.line != "" && r.line[0] == '#' {
+			r.unscan()
+			return true
+		}
+	}
+	return false
+}
+
 // ReadNotes parses an [ultrastar.Notes] from r.
 // If the notes end with an end tag (a line starting with 'E') r may not be read until the end.
 //
@@ -282,21 +598,33 @@ func (r *Reader) ReadNotes() (ultrastar.Notes, error) {
 // The encoding name should identify a supported [charmap.Charmap].
 // If the encoding is unknown or cannot be applied, the returned error will be non-nil.
 func (r *Reader) applyEncoding(s *ultrastar.Song) error {
-	var t transform.Transformer
-	switch strings.ToLower(r.Encoding) {
+	enc, err := encodingByName(r.Encoding)
+	if err != nil || enc == nil {
+		return err
+	}
+	return TransformSong(s, enc.NewDecoder())
+}
+
+// encodingByName returns the [charmap.Charmap] identified by name, a value of the #ENCODING tag.
+// For UTF-8 the result is nil.
+// If the encoding is unknown, ErrUnknownEncoding is returned.
+func encodingByName(name string) (*charmap.Charmap, error) {
+	switch strings.ToLower(name) {
 	case "", "auto", "utf8", "utf-8":
 		// This is the default
-		return nil
+		return nil, nil
 	case "cp1250", "cp-1250", "windows1250", "windows-1250":
-		t = charmap.Windows1250.NewDecoder()
+		return charmap.Windows1250, nil
 	case "cp1252", "cp-1252", "windows1252", "windows-1252":
-		t = charmap.Windows1252.NewDecoder()
+		return charmap.Windows1252, nil
+	case "cp1251", "cp-1251", "windows1251", "windows-1251":
+		return charmap.Windows1251, nil
+	case "iso8859-2", "iso-8859-2", "latin2", "latin-2":
+		return charmap.ISO8859_2, nil
 	// FIXME: Do we want to support additional encodings?
 	default:
-		return ErrUnknownEncoding
+		return nil, ErrUnknownEncoding
 	}
-
-	return TransformSong(s, t)
 }
 
 // ReadTags reads a set of tags from the input and returns a song with the tags set.
@@ -304,39 +632,118 @@ func (r *Reader) applyEncoding(s *ultrastar.Song) error {
 func (r *Reader) ReadTags() (ultrastar.Song, error) {
 	r.setupScanner()
 	song := ultrastar.Song{}
+	r.BlankTags = nil
 	var tag, value string
+	tags := 0
 	for r.scan() {
 		if r.line == "" || r.line[0] != '#' {
 			r.unscan()
 			break
 		}
-		tag, value = splitTag(r.line)
+		if strings.TrimSpace(r.line) == "#" {
+			if r.PreserveBlankTags {
+				r.BlankTags = append(r.BlankTags, tags)
+			}
+			continue
+		}
+		tags++
+		tag, value = splitTag(r.line, r.SpaceSeparatedHeaders, r.TrimTagValues)
 		if tag == TagRelative {
 			if !r.AllowRelative {
 				return song, ErrRelativeNotAllowed
 			}
-			r.Relative = strings.ToUpper(value) == "YES"
+			r.Relative = strings.ToUpper(strings.TrimSpace(value)) == "YES"
 		} else if tag == TagEncoding {
 			if r.Encoding == "" {
-				r.Encoding = value
+				r.Encoding = strings.TrimSpace(value)
 			}
-		} else if err := setTag(&song, tag, value, r.AllowInternationalFloat); err != nil {
+		} else if err := setTag(&song, tag, value, tagOptions{
+			internationalFloat: r.AllowInternationalFloat,
+			trimValues:         r.TrimTagValues,
+			tolerateUnits:      r.TolerateTrailingUnits,
+		}); err != nil {
 			return song, err
+		} else if r.preservesRawValue(tag) {
+			if song.CustomTags == nil {
+				song.CustomTags = make(map[string]string)
+			}
+			song.CustomTags[tag] = value
 		}
 	}
 	return song, r.err
 }
 
+// preservesRawValue indicates whether the original value of tag is stored in the custom tags of a song.
+func (r *Reader) preservesRawValue(tag string) bool {
+	switch tag {
+	case TagBPM:
+		return r.PreserveBPMString
+	case TagMedleyStartBeat, TagMedleyEndBeat:
+		return r.PreserveMedleyStrings
+	default:
+		return false
+	}
+}
+
 // splitTag is a helper method that splits a single tag line into key and value.
-func splitTag(line string) (string, string) {
+// If spaceSeparated is true and line does not contain a colon,
+// tag and value are separated at the first space or tab instead.
+// If trimValue is true, leading and trailing whitespace is removed from the value.
+func splitTag(line string, spaceSeparated bool, trimValue bool) (string, string) {
 	var tag, value string
 	index := strings.Index(line, ":")
+	if index < 0 && spaceSeparated {
+		trimmed := strings.TrimLeft(line[1:], " \t")
+		if i := strings.IndexAny(trimmed, " \t"); i >= 0 {
+			index = len(line) - len(trimmed) + i
+		}
+	}
 	if index < 0 {
 		tag, value = line[1:], ""
 	} else {
 		tag, value = line[1:index], line[index+1:]
 	}
-	return CanonicalTagName(strings.TrimSpace(tag)), strings.TrimSpace(value)
+	if trimValue {
+		value = strings.TrimSpace(value)
+	}
+	return CanonicalTagName(strings.TrimSpace(tag)), value
+}
+
+// noteOptions returns the options for parsing notes according to the configuration of r.
+func (r *Reader) noteOptions() noteOptions {
+	return noteOptions{
+		relative:           r.Relative,
+		strict:             r.StrictLineBreaks,
+		spaceOnly:          r.RequireSpaceSeparator,
+		emptyFreestyleText: r.AllowEmptyFreestyleText,
+	}
+}
+
+// fixColumnSwap swaps the duration and pitch of n if r.AutoFixColumnSwap is set
+// and n seems to have its duration and pitch columns swapped.
+// If n is modified a warning is recorded.
+func (r *Reader) fixColumnSwap(n *ultrastar.Note) {
+	if !r.AutoFixColumnSwap || n.Duration > 0 || n.Pitch <= 0 {
+		return
+	}
+	n.Duration, n.Pitch = ultrastar.Beat(n.Pitch), ultrastar.Pitch(n.Duration)
+	r.warn(ErrSwappedColumns)
+}
+
+// unescapeText replaces \n sequences in the text of n if r.UnescapeNoteText is set.
+func (r *Reader) unescapeText(n *ultrastar.Note) {
+	if r.UnescapeNoteText {
+		n.Text = strings.ReplaceAll(n.Text, `\n`, r.NoteTextNewline)
+	}
+}
+
+// checkBackwardsJump records a warning if r is in relative mode and start is before *last.
+// Afterwards *last is set to start.
+func (r *Reader) checkBackwardsJump(start ultrastar.Beat, last *ultrastar.Beat) {
+	if r.Relative && start < *last {
+		r.warn(ErrBackwardsJump)
+	}
+	*last = start
 }
 
 // readNotes parses the [ultrastar.Notes] of a song.
@@ -348,6 +755,7 @@ func (r *Reader) readNotes(allowDuet bool) (ultrastar.Notes, ultrastar.Notes, er
 	var (
 		player int
 		rel    [2]ultrastar.Beat
+		last   [2]ultrastar.Beat
 		notes  [2]ultrastar.Notes
 	)
 
@@ -357,25 +765,40 @@ func (r *Reader) readNotes(allowDuet bool) (ultrastar.Notes, ultrastar.Notes, er
 	duet := r.line != "" && r.line[0] == 'P'
 	r.unscan()
 
+	truncated := false
+	ended := false
 LineLoop:
 	for r.scan() {
+		if r.MaxNotes > 0 && len(notes[0])+len(notes[1]) >= r.MaxNotes {
+			r.unscan()
+			truncated = true
+			break
+		}
+		if r.LenientEndTag && strings.HasPrefix(strings.TrimLeft(r.line, " \t"), "E") {
+			ended = true
+			break
+		}
 		if r.line == "" {
 			return nil, nil, ErrEmptyLine
 		}
 		switch r.line[0] {
 		case uint8(ultrastar.NoteTypeRegular), uint8(ultrastar.NoteTypeGolden), uint8(ultrastar.NoteTypeFreestyle), uint8(ultrastar.NoteTypeRap), uint8(ultrastar.NoteTypeGoldenRap):
-			note, err := parseNoteRelative(r.line, r.Relative, r.StrictLineBreaks)
+			note, err := parseNoteRelative(r.line, r.noteOptions())
 			if err != nil {
 				return nil, nil, ErrInvalidNote
 			}
+			r.fixColumnSwap(&note)
+			r.unescapeText(&note)
 			note.Start += rel[player]
+			r.checkBackwardsJump(note.Start, &last[player])
 			notes[player] = append(notes[player], note)
 		case uint8(ultrastar.NoteTypeLineBreak):
-			note, err := parseNoteRelative(r.line, r.Relative, r.StrictLineBreaks)
+			note, err := parseNoteRelative(r.line, r.noteOptions())
 			if err != nil {
 				return nil, nil, ErrInvalidLineBreak
 			}
 			note.Start += rel[player]
+			r.checkBackwardsJump(note.Start, &last[player])
 			rel[player] += note.Duration
 			note.Duration = 0
 			notes[player] = append(notes[player], note)
@@ -396,17 +819,48 @@ LineLoop:
 			if r.StrictEndTag && strings.TrimSpace(r.line[1:]) != "" {
 				return nil, nil, ErrInvalidEndTag
 			}
+			ended = true
 			break LineLoop
 		default:
-			return nil, nil, fmt.Errorf("%c: %wr", r.line[0], ErrUnknownEvent)
+			var (
+				note ultrastar.Note
+				err  error
+			)
+			if !r.StrictNoteTypes {
+				opts := r.noteOptions()
+				opts.anyType = true
+				note, err = parseNoteRelative(r.line, opts)
+			} else if r.UnknownNotesAsFreestyle {
+				note, err = parseNoteRelative(string(ultrastar.NoteTypeFreestyle)+r.line[1:], r.noteOptions())
+				r.warn(fmt.Errorf("%c: %w", r.line[0], ErrUnknownEvent))
+			} else {
+				return nil, nil, fmt.Errorf("%c: %w", r.line[0], ErrUnknownEvent)
+			}
+			if err != nil {
+				return nil, nil, ErrInvalidNote
+			}
+			r.fixColumnSwap(&note)
+			r.unescapeText(&note)
+			note.Start += rel[player]
+			r.checkBackwardsJump(note.Start, &last[player])
+			notes[player] = append(notes[player], note)
 		}
 	}
 	if r.err != nil {
 		return nil, nil, r.err
 	}
-	if r.EndTagRequired && r.line[0] != 'E' {
+	if r.EndTagRequired && !truncated && !ended {
 		return nil, nil, ErrMissingEndTag
 	}
+	if duet {
+		// A duet may specify notes for only one of the players.
+		// Both players still exist, so we ensure non-nil values.
+		for i := range notes {
+			if notes[i] == nil {
+				notes[i] = ultrastar.Notes{}
+			}
+		}
+	}
 	sort.Sort(notes[0])
 	sort.Sort(notes[1])
 	return notes[0], notes[1], nil
@@ -1,10 +1,17 @@
 package txt
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"codello.dev/ultrastar"
 )
@@ -62,6 +69,25 @@ P 2
 		}
 	})
 
+	t.Run("duet with only P2", func(t *testing.T) {
+		s, err := ParseSong(`#BPM:2
+P2
+: 1 2 4 Some
+: 3 4 5 body`)
+		if err != nil {
+			t.Errorf("ParseSong() caused an unexpected error: %s", err)
+		}
+		if !s.IsDuet() {
+			t.Errorf("s.IsDuet() = false, expected true")
+		}
+		if s.NotesP1 == nil || len(s.NotesP1) != 0 {
+			t.Errorf("s.NotesP1 = %v, expected empty notes", s.NotesP1)
+		}
+		if len(s.NotesP2) != 2 {
+			t.Errorf("len(s.NotesP2) = %d, expected 2", len(s.NotesP2))
+		}
+	})
+
 	t.Run("unexpected P number", func(t *testing.T) {
 		_, err := ParseSong(`#BPM: 20
 : 1 2 4 Some
@@ -219,3 +245,604 @@ with multiple lines.`)
 		}
 	})
 }
+
+// chunkReader is an io.Reader that returns at most n bytes per Read call.
+type chunkReader struct {
+	r io.Reader
+	n int
+}
+
+// Read implements io.Reader.
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	return r.r.Read(p)
+}
+
+func FuzzReader_BOM(f *testing.F) {
+	f.Add("All Star", 1, true)
+	f.Add("Perfekte Welle", 2, false)
+	f.Add("Träume", 3, true)
+	f.Fuzz(func(t *testing.T, title string, chunkSize int, bom bool) {
+		if chunkSize <= 0 || chunkSize > 64 || !utf8.ValidString(title) ||
+			strings.ContainsAny(title, "\r\n") || strings.HasPrefix(title, "\uFEFF") {
+			t.Skip()
+		}
+		input := "#TITLE:" + title + "\n: 1 2 3 some\n"
+		if bom {
+			input = "\uFEFF" + input
+		}
+		s, err := NewReader(&chunkReader{strings.NewReader(input), chunkSize}).ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		if expected := strings.TrimSpace(title); s.Title != expected {
+			t.Errorf("s.Title = %q, expected %q", s.Title, expected)
+		}
+		if len(s.NotesP1) != 1 {
+			t.Errorf("len(s.NotesP1) = %d, expected 1", len(s.NotesP1))
+		}
+	})
+}
+
+func TestReader_MaxNotes(t *testing.T) {
+	f, _ := os.Open("testdata/Smash Mouth - All Star.txt")
+	defer f.Close()
+	r := NewReader(f)
+	r.MaxNotes = 10
+	r.EndTagRequired = true
+	s, err := r.ReadSong()
+	if err != nil {
+		t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+	}
+	if len(s.NotesP1) != 10 {
+		t.Errorf("len(s.NotesP1) = %d, expected 10", len(s.NotesP1))
+	}
+	if s.Artist != "Smash Mouth" {
+		t.Errorf("ParseSong() set s.Artist to %q, expected %q", s.Artist, "Smash Mouth")
+	}
+}
+
+func TestReader_BytesRead(t *testing.T) {
+	f, _ := os.Open("testdata/Smash Mouth - All Star.txt")
+	defer f.Close()
+	stat, _ := f.Stat()
+	r := NewReader(f)
+	if r.BytesRead() != 0 {
+		t.Errorf("r.BytesRead() = %d before reading, expected 0", r.BytesRead())
+	}
+	if _, err := r.ReadSong(); err != nil {
+		t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+	}
+	if r.BytesRead() != stat.Size() {
+		t.Errorf("r.BytesRead() = %d, expected %d", r.BytesRead(), stat.Size())
+	}
+	r.Reset(strings.NewReader(""))
+	if r.BytesRead() != 0 {
+		t.Errorf("r.BytesRead() = %d after r.Reset(), expected 0", r.BytesRead())
+	}
+}
+
+func TestReader_MaxLineBytes(t *testing.T) {
+	cases := map[string]struct {
+		song string
+		err  bool
+	}{
+		"short lines":     {"#TITLE:" + strings.Repeat("a", 13) + "\r\n: 1 2 0 Some\n", false},
+		"long tag":        {"#TITLE:" + strings.Repeat("a", 14) + "\n: 1 2 0 Some\n", true},
+		"very long tag":   {"#TITLE:" + strings.Repeat("a", 10000) + "\n: 1 2 0 Some\n", true},
+		"long final line": {"#TITLE:Foo\n: 1 2 0 " + strings.Repeat("a", 100), true},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(c.song))
+			r.MaxLineBytes = 20
+			_, err := r.ReadSong()
+			if c.err && !errors.Is(err, ErrLineTooLong) {
+				t.Errorf("ReadSong() did not cause ErrLineTooLong, but: %v", err)
+			} else if !c.err && err != nil {
+				t.Errorf("ReadSong() caused an unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestReader_DefaultLineLimit(t *testing.T) {
+	song := "#TITLE:" + strings.Repeat("a", bufio.MaxScanTokenSize) + "\n: 1 2 0 Some\n"
+	_, err := ParseSong(song)
+	if !errors.Is(err, ErrLineTooLong) {
+		t.Fatalf("ParseSong() did not cause ErrLineTooLong, but: %v", err)
+	}
+	expected := fmt.Sprintf("more than %d bytes", bufio.MaxScanTokenSize)
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("ParseSong() = %q, expected it to contain %q", err, expected)
+	}
+}
+
+func TestReader_LenientEndTag(t *testing.T) {
+	cases := map[string]struct {
+		line  string
+		notes int
+	}{
+		"spaces":    {"E  ", 1},
+		"tabs":      {"E\t\t", 1},
+		"word":      {"End", 1},
+		"indented":  {"  E", 1},
+		"note line": {": 4 2 0 End", 3},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReader(strings.NewReader("#BPM:12\n: 1 2 0 Some\n" + c.line + "\n: 8 2 0 body\n"))
+			r.LenientEndTag = true
+			s, err := r.ReadSong()
+			if err != nil {
+				t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+			}
+			if len(s.NotesP1) != c.notes {
+				t.Errorf("len(s.NotesP1) = %d, expected %d", len(s.NotesP1), c.notes)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		if _, err := ParseSong("#BPM:12\n: 1 2 0 Some\nEnd\n"); !errors.Is(err, ErrInvalidEndTag) {
+			t.Errorf("ParseSong() did not cause ErrInvalidEndTag, but: %v", err)
+		}
+	})
+}
+
+func TestReader_AllowGzip(t *testing.T) {
+	song := "#TITLE:Foo\n#BPM:12\n: 1 2 0 Some\n: 3 2 0 body\nE\n"
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte(song))
+	_ = gz.Close()
+	compressed := buf.Bytes()
+
+	t.Run("plain", func(t *testing.T) {
+		s, err := NewReader(strings.NewReader(song)).ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		if s.Title != "Foo" || len(s.NotesP1) != 2 {
+			t.Errorf("ReadSong() = %v, expected title %q and 2 notes", s, "Foo")
+		}
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		s, err := NewReader(bytes.NewReader(compressed)).ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		if s.Title != "Foo" || len(s.NotesP1) != 2 {
+			t.Errorf("ReadSong() = %v, expected title %q and 2 notes", s, "Foo")
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader(compressed[:len(compressed)-10])).ReadSong()
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("ReadSong() = %v, expected %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r := NewReader(bytes.NewReader(compressed))
+		r.AllowGzip = false
+		if _, err := r.ReadSong(); err == nil {
+			t.Errorf("ReadSong() did not cause an error")
+		}
+	})
+}
+
+func TestReader_ApplyResolution(t *testing.T) {
+	song := "#RESOLUTION:8\n#BPM:12\n#MEDLEYSTARTBEAT:8\n: 2 4 0 Some\n- 7\n: 8 2 0 body\n"
+	t.Run("disabled", func(t *testing.T) {
+		s, err := ParseSong(song)
+		if err != nil {
+			t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+		}
+		if s.NotesP1[2].Start != 8 {
+			t.Errorf("s.NotesP1[2].Start = %d, expected 8", s.NotesP1[2].Start)
+		}
+		if s.CustomTags[TagResolution] != "8" {
+			t.Errorf("s.CustomTags[%q] = %q, expected %q", TagResolution, s.CustomTags[TagResolution], "8")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.ApplyResolution = true
+		s, err := r.ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		expected := ultrastar.Notes{
+			{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Text: "Some"},
+			{Type: ultrastar.NoteTypeLineBreak, Start: 4, Text: "\n"},
+			{Type: ultrastar.NoteTypeRegular, Start: 4, Duration: 1, Text: "body"},
+		}
+		if !s.NotesP1.Equal(expected) {
+			t.Errorf("s.NotesP1 = %v, expected %v", s.NotesP1, expected)
+		}
+		if s.MedleyStartBeat != 4 {
+			t.Errorf("s.MedleyStartBeat = %d, expected 4", s.MedleyStartBeat)
+		}
+		if _, ok := s.CustomTags[TagResolution]; ok {
+			t.Errorf("s.CustomTags contains %q, expected it to be removed", TagResolution)
+		}
+		if r.Resolution != 8 {
+			t.Errorf("r.Resolution = %d, expected 8", r.Resolution)
+		}
+	})
+}
+
+func TestReader_UnescapeNoteText(t *testing.T) {
+	song := "#BPM:12\n: 1 2 3 a\\nb\n"
+	cases := map[string]struct {
+		unescape    bool
+		replacement string
+		expected    string
+	}{
+		"disabled":    {false, " ", "a\\nb"},
+		"space":       {true, " ", "a b"},
+		"replacement": {true, "/", "a/b"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(song))
+			r.UnescapeNoteText = c.unescape
+			r.NoteTextNewline = c.replacement
+			s, err := r.ReadSong()
+			if err != nil {
+				t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+			}
+			if s.NotesP1[0].Text != c.expected {
+				t.Errorf("s.NotesP1[0].Text = %q, expected %q", s.NotesP1[0].Text, c.expected)
+			}
+		})
+	}
+}
+
+func TestReader_UnknownNotesAsFreestyle(t *testing.T) {
+	song := `#BPM:12
+: 1 2 0 Some
+Q 1 2 3 foo
+`
+	t.Run("disabled", func(t *testing.T) {
+		_, err := ParseSong(song)
+		if !errors.Is(err, ErrUnknownEvent) {
+			t.Errorf("ParseSong() did not cause ErrUnknownEvent, but: %s", err)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.UnknownNotesAsFreestyle = true
+		s, err := r.ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		expected := ultrastar.Note{Type: ultrastar.NoteTypeFreestyle, Start: 1, Duration: 2, Pitch: 3, Text: "foo"}
+		if len(s.NotesP1) != 2 {
+			t.Fatalf("len(s.NotesP1) = %d, expected 2", len(s.NotesP1))
+		}
+		if s.NotesP1[1] != expected {
+			t.Errorf("s.NotesP1[1] = %v, expected %v", s.NotesP1[1], expected)
+		}
+		warnings := r.Warnings()
+		if len(warnings) != 1 {
+			t.Fatalf("len(r.Warnings()) = %d, expected 1", len(warnings))
+		}
+		var pErr ParseError
+		if !errors.As(warnings[0], &pErr) || pErr.Line() != 3 {
+			t.Errorf("r.Warnings()[0] = %v, expected a ParseError at line 3", warnings[0])
+		}
+		if !errors.Is(warnings[0], ErrUnknownEvent) {
+			t.Errorf("r.Warnings()[0] = %v, expected ErrUnknownEvent", warnings[0])
+		}
+	})
+}
+
+func TestReader_StrictNoteTypes(t *testing.T) {
+	song := "#TITLE:Foo\n#ENCODING:CP1252\n#BPM:12\n: 1 2 0 Some\nQ 3 2 3 foo\n- 6\n: 7 2 0 body\nE\n"
+	t.Run("strict", func(t *testing.T) {
+		_, err := ParseSong(song)
+		if !errors.Is(err, ErrUnknownEvent) {
+			t.Errorf("ParseSong() did not cause ErrUnknownEvent, but: %s", err)
+		}
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.StrictNoteTypes = false
+		s, err := r.ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		expected := ultrastar.Note{Type: 'Q', Start: 3, Duration: 2, Pitch: 3, Text: "foo"}
+		if len(s.NotesP1) != 4 || s.NotesP1[1] != expected {
+			t.Fatalf("s.NotesP1 = %v, expected %v at index 1", s.NotesP1, expected)
+		}
+		if err = s.Validate(); !errors.Is(err, ultrastar.ErrUnknownNoteType) {
+			t.Errorf("s.Validate() = %v, expected %v", err, ultrastar.ErrUnknownNoteType)
+		}
+		if lyrics := s.Lyrics(); lyrics != "Somefoo\nbody" {
+			t.Errorf("s.Lyrics() = %q, expected %q", lyrics, "Somefoo\nbody")
+		}
+		b := &strings.Builder{}
+		if err = WriteSong(b, s); err != nil {
+			t.Fatalf("WriteSong() caused an unexpected error: %s", err)
+		}
+		expectedSong := "#TITLE:Foo\n#BPM:12\n: 1 2 0 Some\nQ 3 2 3 foo\n- 6\n: 7 2 0 body\nE\n"
+		if b.String() != expectedSong {
+			t.Errorf("WriteSong() resulted in %q, expected %q", b.String(), expectedSong)
+		}
+	})
+}
+
+func TestReader_AutoFixColumnSwap(t *testing.T) {
+	song := "#BPM:12\n: 1 2 0 Some\n: 4 -3 5 body\n"
+	t.Run("disabled", func(t *testing.T) {
+		s, err := ParseSong(song)
+		if err != nil {
+			t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+		}
+		expected := ultrastar.Note{Type: ultrastar.NoteTypeRegular, Start: 4, Duration: -3, Pitch: 5, Text: "body"}
+		if s.NotesP1[1] != expected {
+			t.Errorf("s.NotesP1[1] = %v, expected %v", s.NotesP1[1], expected)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.AutoFixColumnSwap = true
+		s, err := r.ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		expected := ultrastar.Note{Type: ultrastar.NoteTypeRegular, Start: 4, Duration: 5, Pitch: -3, Text: "body"}
+		if s.NotesP1[1] != expected {
+			t.Errorf("s.NotesP1[1] = %v, expected %v", s.NotesP1[1], expected)
+		}
+		warnings := r.Warnings()
+		if len(warnings) != 1 {
+			t.Fatalf("len(r.Warnings()) = %d, expected 1", len(warnings))
+		}
+		if !errors.Is(warnings[0], ErrSwappedColumns) {
+			t.Errorf("r.Warnings()[0] = %v, expected ErrSwappedColumns", warnings[0])
+		}
+	})
+}
+
+func TestReader_BackwardsJump(t *testing.T) {
+	cases := map[string]struct {
+		song     string
+		warnings int
+	}{
+		"consistent": {"#RELATIVE:YES\n: 0 2 0 Some\n: 4 2 0 body\n- 8 10\n: 0 2 0 once\n: 2 2 0 told\n", 0},
+		"backwards":  {"#RELATIVE:YES\n: 0 2 0 Some\n: 4 2 0 body\n- 8 10\n: 4 2 0 once\n: 2 2 0 told\n", 1},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(c.song))
+			s, err := r.ReadSong()
+			if err != nil {
+				t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+			}
+			if len(s.NotesP1) != 5 {
+				t.Errorf("len(s.NotesP1) = %d, expected 5", len(s.NotesP1))
+			}
+			warnings := r.Warnings()
+			if len(warnings) != c.warnings {
+				t.Fatalf("len(r.Warnings()) = %d, expected %d", len(warnings), c.warnings)
+			}
+			for _, w := range warnings {
+				var pErr ParseError
+				if !errors.As(w, &pErr) || pErr.Line() != 6 || !errors.Is(w, ErrBackwardsJump) {
+					t.Errorf("r.Warnings() contains %v, expected ErrBackwardsJump at line 6", w)
+				}
+			}
+		})
+	}
+}
+
+func TestReader_BPMFromFirstChange(t *testing.T) {
+	song := "#TITLE:Foo\nB 0 120,5\n: 1 2 0 Some\n"
+	t.Run("disabled", func(t *testing.T) {
+		_, err := ParseSong(song)
+		if !errors.Is(err, ErrMultiBPM) {
+			t.Errorf("ParseSong() did not cause ErrMultiBPM, but: %v", err)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.BPMFromFirstChange = true
+		s, err := r.ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		if s.BPM != 482 {
+			t.Errorf("s.BPM = %f, expected %f", s.BPM, ultrastar.BPM(482))
+		}
+		if len(s.NotesP1) != 1 {
+			t.Errorf("len(s.NotesP1) = %d, expected 1", len(s.NotesP1))
+		}
+	})
+
+	t.Run("header BPM", func(t *testing.T) {
+		r := NewReader(strings.NewReader("#BPM:100\n" + song))
+		r.BPMFromFirstChange = true
+		if _, err := r.ReadSong(); !errors.Is(err, ErrMultiBPM) {
+			t.Errorf("ReadSong() did not cause ErrMultiBPM, but: %v", err)
+		}
+	})
+}
+
+func TestReader_RequireSpaceSeparator(t *testing.T) {
+	song := "#BPM:12\n:\t1\t2\t0\tSome\n"
+	t.Run("disabled", func(t *testing.T) {
+		s, err := ParseSong(song)
+		if err != nil {
+			t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+		}
+		expected := ultrastar.Note{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Pitch: 0, Text: "Some"}
+		if len(s.NotesP1) != 1 || s.NotesP1[0] != expected {
+			t.Errorf("s.NotesP1 = %v, expected [%v]", s.NotesP1, expected)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.RequireSpaceSeparator = true
+		_, err := r.ReadSong()
+		if !errors.Is(err, ErrInvalidNote) {
+			t.Errorf("ReadSong() did not cause ErrInvalidNote, but: %v", err)
+		}
+	})
+}
+
+func TestReader_SpaceSeparatedHeaders(t *testing.T) {
+	song := "#TITLE Some Title\n#ARTIST:Smash Mouth\n#FOO\n: 1 2 0 Some\n"
+	t.Run("disabled", func(t *testing.T) {
+		s, err := ParseSong(song)
+		if err != nil {
+			t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+		}
+		if s.Title != "" {
+			t.Errorf("s.Title = %q, expected %q", s.Title, "")
+		}
+		if _, ok := s.CustomTags["TITLE SOME TITLE"]; !ok {
+			t.Errorf("s.CustomTags does not contain %q", "TITLE SOME TITLE")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.SpaceSeparatedHeaders = true
+		s, err := r.ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		if s.Title != "Some Title" {
+			t.Errorf("s.Title = %q, expected %q", s.Title, "Some Title")
+		}
+		if s.Artist != "Smash Mouth" {
+			t.Errorf("s.Artist = %q, expected %q", s.Artist, "Smash Mouth")
+		}
+		if value, ok := s.CustomTags["FOO"]; !ok || value != "" {
+			t.Errorf("s.CustomTags[%q] = %q, expected %q", "FOO", value, "")
+		}
+	})
+}
+
+func TestReadAllSongs(t *testing.T) {
+	songs, err := ReadAllSongs(strings.NewReader(`#TITLE:First
+#BPM:12
+: 1 2 0 Some
+: 3 2 0 body
+E
+#TITLE:Second
+#RELATIVE:YES
+#BPM:24
+: 1 2 0 once
+- 4 4
+: 1 2 0 told
+E
+`))
+	if err != nil {
+		t.Fatalf("ReadAllSongs() caused an unexpected error: %s", err)
+	}
+	if len(songs) != 2 {
+		t.Fatalf("len(songs) = %d, expected 2", len(songs))
+	}
+	if songs[0].Title != "First" || len(songs[0].NotesP1) != 2 {
+		t.Errorf("songs[0] = %v, expected song %q with 2 notes", songs[0], "First")
+	}
+	if songs[1].Title != "Second" || len(songs[1].NotesP1) != 3 {
+		t.Errorf("songs[1] = %v, expected song %q with 3 notes", songs[1], "Second")
+	}
+	if songs[1].NotesP1[2].Start != 5 {
+		t.Errorf("songs[1].NotesP1[2].Start = %d, expected 5", songs[1].NotesP1[2].Start)
+	}
+}
+
+func TestReader_Encoding(t *testing.T) {
+	cases := map[string]struct {
+		encoding string
+		text     string
+		expected string
+	}{
+		"CP1252":     {"CP1252", "Tr\xe4u", "Träu"},
+		"CP1251":     {"CP1251", "\xcf\xf0\xe8", "При"},
+		"ISO-8859-2": {"ISO-8859-2", "\xb1", "ą"},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s, err := ParseSong("#ENCODING:" + c.encoding + "\n#BPM:12\n: 1 2 0 " + c.text + "\n")
+			if err != nil {
+				t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+			}
+			if s.NotesP1[0].Text != c.expected {
+				t.Errorf("s.NotesP1[0].Text = %q, expected %q", s.NotesP1[0].Text, c.expected)
+			}
+		})
+	}
+}
+
+func TestReader_TrimTagValues(t *testing.T) {
+	song := "#TITLE:Some Title  \n#BPM: 12 \n: 1 2 0 Some\n"
+	t.Run("enabled", func(t *testing.T) {
+		s, err := ParseSong(song)
+		if err != nil {
+			t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+		}
+		if s.Title != "Some Title" {
+			t.Errorf("s.Title = %q, expected %q", s.Title, "Some Title")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.TrimTagValues = false
+		s, err := r.ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		if s.Title != "Some Title  " {
+			t.Errorf("s.Title = %q, expected %q", s.Title, "Some Title  ")
+		}
+		if s.BPM != 48 {
+			t.Errorf("s.BPM = %f, expected %f", s.BPM, ultrastar.BPM(48))
+		}
+	})
+}
+
+func TestReader_TolerateTrailingUnits(t *testing.T) {
+	song := "#YEAR:1999 AD\n#BPM:120 bpm\n#GAP:300ms\n: 1 2 0 Some\n"
+	t.Run("disabled", func(t *testing.T) {
+		if _, err := ParseSong(song); err == nil {
+			t.Errorf("ParseSong() did not cause an error, expected one")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		r := NewReader(strings.NewReader(song))
+		r.TolerateTrailingUnits = true
+		s, err := r.ReadSong()
+		if err != nil {
+			t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+		}
+		if s.Year != 1999 {
+			t.Errorf("s.Year = %d, expected %d", s.Year, 1999)
+		}
+		if s.BPM != 480 {
+			t.Errorf("s.BPM = %f, expected %f", s.BPM, ultrastar.BPM(480))
+		}
+		if s.Gap != 300*time.Millisecond {
+			t.Errorf("s.Gap = %s, expected %s", s.Gap, 300*time.Millisecond)
+		}
+	})
+}
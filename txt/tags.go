@@ -3,6 +3,8 @@ package txt
 import (
 	"errors"
 	"math"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -41,6 +43,30 @@ const (
 	// The value is a file path relative to the TXT file.
 	TagBackground = "BACKGROUND"
 
+	// TagAudioURL references a web page of the song's audio.
+	// The value is a URL.
+	TagAudioURL = "AUDIOURL"
+
+	// TagVideoURL references a web page of the song's video.
+	// The value is a URL.
+	TagVideoURL = "VIDEOURL"
+
+	// TagCoverURL references a web page of the song's artwork.
+	// The value is a URL.
+	TagCoverURL = "COVERURL"
+
+	// TagBackgroundURL references a web page of the song's background image.
+	// The value is a URL.
+	TagBackgroundURL = "BACKGROUNDURL"
+
+	// TagVocalsURL references a web page of the song's vocals track.
+	// The value is a URL.
+	TagVocalsURL = "VOCALSURL"
+
+	// TagInstrumentalURL references a web page of the song's instrumental track.
+	// The value is a URL.
+	TagInstrumentalURL = "INSTRUMENTALURL"
+
 	// TagBPM identifies the starting BPM for a song.
 	// In most cases this BPM value holds for the entire duration of a song but
 	// Multi BPM songs are supported by UltraStar.
@@ -83,7 +109,8 @@ const (
 	// TagResolution is a tag that pops up in old documentation from time to time.
 	// In TXT based songs this tag does not have any effect.
 	// This tag originates from songs that were parsed from MIDI files (where the resolution does have an effect).
-	// This library treats this as a custom tag with no special meaning.
+	// This library treats this as a custom tag with no special meaning,
+	// unless [Reader.ApplyResolution] is set.
 	//
 	// The value is an integer, an absent value is equivalent to 4.
 	TagResolution = "RESOLUTION"
@@ -160,6 +187,38 @@ const (
 	TagP2 = "P2"
 )
 
+// knownTags is the set of all tags defined by this package.
+var knownTags = map[string]struct{}{
+	TagRelative: {}, TagEncoding: {}, TagMP3: {}, TagVideo: {}, TagCover: {}, TagBackground: {},
+	TagAudioURL: {}, TagVideoURL: {}, TagCoverURL: {}, TagBackgroundURL: {}, TagVocalsURL: {}, TagInstrumentalURL: {},
+	TagBPM: {}, TagGap: {}, TagVideoGap: {}, TagNotesGap: {}, TagStart: {}, TagEnd: {},
+	TagResolution: {}, TagPreviewStart: {}, TagMedleyStartBeat: {}, TagMedleyEndBeat: {},
+	TagCalcMedley: {}, TagTitle: {}, TagArtist: {}, TagGenre: {}, TagEdition: {}, TagCreator: {},
+	TagAuthor: {}, TagLanguage: {}, TagYear: {}, TagComment: {}, TagDuetSingerP1: {},
+	TagDuetSingerP2: {}, TagP1: {}, TagP2: {},
+}
+
+// IsKnownTag indicates whether tag is one of the tags defined by this package.
+// Tag names are compared case-insensitively.
+func IsKnownTag(tag string) bool {
+	_, ok := knownTags[CanonicalTagName(tag)]
+	return ok
+}
+
+// CustomTags returns the truly custom tags of s.
+// Known tags without a corresponding field in [ultrastar.Song] (such as #RESOLUTION)
+// are stored in s.CustomTags.
+// This function returns a copy of s.CustomTags with all known tags removed (see [IsKnownTag]).
+func CustomTags(s ultrastar.Song) map[string]string {
+	tags := make(map[string]string, len(s.CustomTags))
+	for tag, value := range s.CustomTags {
+		if !IsKnownTag(tag) {
+			tags[tag] = value
+		}
+	}
+	return tags
+}
+
 // CanonicalTagName returns the normalized version of the specified tag name
 // (that is: the uppercase version).
 func CanonicalTagName(name string) string {
@@ -174,20 +233,65 @@ func CanonicalTagName(name string) string {
 // If an error occurs during conversion it is returned.
 // Otherwise, nil is returned.
 func SetTag(s *ultrastar.Song, tag string, value string) error {
-	return setTag(s, tag, value, true)
+	return setTag(s, tag, value, tagOptions{internationalFloat: true, trimValues: true})
+}
+
+// tagOptions configure the behavior of setTag.
+type tagOptions struct {
+	// internationalFloat indicates whether floats can use a comma as decimal separator.
+	internationalFloat bool
+	// trimValues indicates whether leading and trailing whitespace is removed from values.
+	// Numeric values are always trimmed.
+	trimValues bool
+	// tolerateUnits indicates whether the values of #BPM, #GAP and #YEAR may have a trailing unit
+	// (any non-numeric suffix) which is ignored.
+	tolerateUnits bool
+}
+
+// MergeTags sets the specified tags (as they would be present in an UltraStar file) on s.
+// Tag names are canonicalized via [CanonicalTagName].
+// If overwrite is true, all tags replace the current values of s.
+// If overwrite is false, only tags that currently have no value in s (as determined by [GetTag]) are set.
+//
+// Tags are processed in sorted order.
+// If a value cannot be converted (see [SetTag]) that tag is skipped and the first such error is returned
+// after all other tags have been merged.
+func MergeTags(s *ultrastar.Song, tags map[string]string, overwrite bool) error {
+	names := make([]string, 0, len(tags))
+	for tag := range tags {
+		names = append(names, tag)
+	}
+	sort.Strings(names)
+	var err error
+	for _, tag := range names {
+		name := CanonicalTagName(strings.TrimSpace(tag))
+		if !overwrite && GetTag(*s, name) != "" {
+			continue
+		}
+		if tErr := SetTag(s, name, tags[tag]); tErr != nil && err == nil {
+			err = tErr
+		}
+	}
+	return err
 }
 
 // setTag implements the [SetTag] function.
-// This implementation allows for an additional parameter configuring whether international floats are supported.
-func setTag(s *ultrastar.Song, tag string, value string, internationalFloat bool) error {
+// This implementation allows for additional options configuring the parsing behavior.
+func setTag(s *ultrastar.Song, tag string, value string, opts tagOptions) error {
 	tag = strings.ToUpper(strings.TrimSpace(tag))
-	value = strings.TrimSpace(value)
+	if opts.trimValues {
+		value = strings.TrimSpace(value)
+	}
+	num := strings.TrimSpace(value)
 	switch tag {
 	case TagRelative:
 		// All songs are in absolute mode. This cannot be set.
 		return errors.New("read only tag: #" + TagRelative)
 	case TagBPM:
-		if bpm, err := parseFloat(value, internationalFloat); err != nil {
+		if opts.tolerateUnits {
+			num = stripUnit(num)
+		}
+		if bpm, err := parseFloat(num, opts.internationalFloat); err != nil {
 			return err
 		} else {
 			s.BPM = ultrastar.BPM(bpm * 4)
@@ -200,50 +304,65 @@ func setTag(s *ultrastar.Song, tag string, value string, internationalFloat bool
 		s.CoverFileName = value
 	case TagBackground:
 		s.BackgroundFileName = value
+	case TagAudioURL:
+		return parseURL(num, &s.AudioURL)
+	case TagVideoURL:
+		return parseURL(num, &s.VideoURL)
+	case TagCoverURL:
+		return parseURL(num, &s.CoverURL)
+	case TagBackgroundURL:
+		return parseURL(num, &s.BackgroundURL)
+	case TagVocalsURL:
+		return parseURL(num, &s.VocalsURL)
+	case TagInstrumentalURL:
+		return parseURL(num, &s.InstrumentalURL)
 	case TagGap:
-		if gap, err := parseFloat(value, internationalFloat); err != nil {
+		if opts.tolerateUnits {
+			num = stripUnit(num)
+		}
+		if gap, err := parseFloat(num, opts.internationalFloat); err != nil {
 			return err
 		} else {
 			s.Gap = time.Duration(gap * float64(time.Millisecond))
 		}
 	case TagVideoGap:
-		if videoGap, err := parseFloat(value, internationalFloat); err != nil {
+		if videoGap, err := parseFloat(num, opts.internationalFloat); err != nil {
 			return err
 		} else {
 			s.VideoGap = time.Duration(videoGap * float64(time.Second))
 		}
 	case TagStart:
-		if start, err := parseFloat(value, internationalFloat); err != nil {
+		if start, err := parseFloat(num, opts.internationalFloat); err != nil {
 			return err
 		} else {
 			s.Start = time.Duration(start * float64(time.Second))
 		}
 	case TagEnd:
-		if end, err := parseFloat(value, internationalFloat); err != nil {
+		if end, err := parseFloat(num, opts.internationalFloat); err != nil {
 			return err
 		} else {
 			s.End = time.Duration(end * float64(time.Millisecond))
 		}
 	case TagPreviewStart:
-		if previewStart, err := parseFloat(value, internationalFloat); err != nil {
+		if previewStart, err := parseFloat(num, opts.internationalFloat); err != nil {
 			return err
 		} else {
 			s.PreviewStart = time.Duration(previewStart * float64(time.Second))
 		}
 	case TagMedleyStartBeat:
-		if beat, err := strconv.Atoi(value); err != nil {
+		if beat, err := strconv.Atoi(num); err != nil {
 			return err
 		} else {
 			s.MedleyStartBeat = ultrastar.Beat(beat)
 		}
 	case TagMedleyEndBeat:
-		if beat, err := strconv.Atoi(value); err != nil {
+		if beat, err := strconv.Atoi(num); err != nil {
 			return err
 		} else {
-			s.MedleyStartBeat = ultrastar.Beat(beat)
+			s.MedleyEndBeat = ultrastar.Beat(beat)
 		}
 	case TagCalcMedley:
-		s.NoAutoMedley = strings.ToUpper(value) == "OFF"
+		s.NoAutoMedley = strings.ToUpper(num) == "OFF"
 	case TagTitle:
 		s.Title = value
 	case TagArtist:
@@ -257,7 +376,10 @@ func setTag(s *ultrastar.Song, tag string, value string, internationalFloat bool
 	case TagLanguage:
 		s.Language = value
 	case TagYear:
-		if year, err := strconv.Atoi(value); err != nil {
+		if opts.tolerateUnits {
+			num = stripUnit(num)
+		}
+		if year, err := strconv.Atoi(num); err != nil {
 			return err
 		} else {
 			s.Year = year
@@ -277,6 +399,34 @@ func setTag(s *ultrastar.Song, tag string, value string, internationalFloat bool
 	return nil
 }
 
+// parseURL parses s into *u.
+// An empty string results in a nil URL.
+func parseURL(s string, u **url.URL) error {
+	if s == "" {
+		*u = nil
+		return nil
+	}
+	v, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// stripUnit removes a non-numeric suffix from s.
+// The numeric prefix of s may contain digits, signs and decimal separators.
+// For example "120 bpm" results in "120".
+func stripUnit(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		if strings.IndexByte("0123456789+-.,", s[i]) < 0 {
+			break
+		}
+	}
+	return strings.TrimSpace(s[:i])
+}
+
 // parseFloat converts a string from an UltraStar txt to a float. This function
 // implements some special parsing behavior to parse UltraStar floats,
 // specifically supporting a comma as decimal separator.
@@ -312,6 +462,12 @@ func getTag(s ultrastar.Song, tag string, commaFloat bool) string {
 		if !s.BPM.IsValid() {
 			return ""
 		}
+		// A preserved BPM string (see Reader.PreserveBPMString) is used if it still matches s.BPM.
+		if raw, ok := s.CustomTags[TagBPM]; ok {
+			if bpm, err := parseFloat(strings.TrimSpace(raw), true); err == nil && ultrastar.BPM(bpm*4) == s.BPM {
+				return raw
+			}
+		}
 		return formatFloatTag(float64(s.BPM/4), commaFloat)
 	case TagMP3:
 		return s.AudioFileName
@@ -321,10 +477,22 @@ func getTag(s ultrastar.Song, tag string, commaFloat bool) string {
 		return s.CoverFileName
 	case TagBackground:
 		return s.BackgroundFileName
+	case TagAudioURL:
+		return formatURL(s.AudioURL)
+	case TagVideoURL:
+		return formatURL(s.VideoURL)
+	case TagCoverURL:
+		return formatURL(s.CoverURL)
+	case TagBackgroundURL:
+		return formatURL(s.BackgroundURL)
+	case TagVocalsURL:
+		return formatURL(s.VocalsURL)
+	case TagInstrumentalURL:
+		return formatURL(s.InstrumentalURL)
 	case TagGap:
 		msec := int64(s.Gap / time.Millisecond)
 		nsec := int64(s.Gap % time.Millisecond)
-		v := float64(msec) + float64(nsec)/1000
+		v := float64(msec) + float64(nsec)/float64(time.Millisecond)
 		return formatFloatTag(v, commaFloat)
 	case TagVideoGap:
 		v := s.VideoGap.Seconds()
@@ -340,9 +508,9 @@ func getTag(s ultrastar.Song, tag string, commaFloat bool) string {
 		v := s.PreviewStart.Seconds()
 		return formatFloatTag(v, commaFloat)
 	case TagMedleyStartBeat:
-		return formatIntTag(int(s.MedleyStartBeat))
+		return formatPreservedIntTag(s, tag, int(s.MedleyStartBeat))
 	case TagMedleyEndBeat:
-		return formatIntTag(int(s.MedleyEndBeat))
+		return formatPreservedIntTag(s, tag, int(s.MedleyEndBeat))
 	case TagCalcMedley:
 		if s.NoAutoMedley {
 			return "OFF"
@@ -373,6 +541,15 @@ func getTag(s ultrastar.Song, tag string, commaFloat bool) string {
 	}
 }
 
+// formatURL formats a URL to be used as a tag value.
+// This method returns an empty string if u is nil.
+func formatURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
 // formatIntTag formats an integer to be used as a tag value.
 // This method returns an empty string if i is 0.
 func formatIntTag(i int) string {
@@ -382,6 +559,18 @@ func formatIntTag(i int) string {
 	return strconv.Itoa(i)
 }
 
+// formatPreservedIntTag formats v like formatIntTag.
+// If s.CustomTags contains a preserved value for tag (see Reader.PreserveMedleyStrings)
+// and that value still corresponds to v, the preserved value is returned instead.
+func formatPreservedIntTag(s ultrastar.Song, tag string, v int) string {
+	if raw, ok := s.CustomTags[tag]; ok {
+		if i, err := strconv.Atoi(strings.TrimSpace(raw)); err == nil && i == v {
+			return raw
+		}
+	}
+	return formatIntTag(v)
+}
+
 // formatFloatTag formats a floating point value to be used as a tag value.
 // This method returns an empty string if v is 0.
 func formatFloatTag(v float64, useComma bool) string {
@@ -123,3 +123,71 @@ func TestSetTag(t *testing.T) {
 }
 
 // TODO: Probably more tag tests
+
+func TestMergeTags(t *testing.T) {
+	tags := map[string]string{
+		"title":  "Other Title",
+		"ARTIST": "Other Artist",
+		"MyTag":  "other value",
+		"YEAR":   "2001",
+	}
+	cases := map[string]struct {
+		overwrite bool
+		expected  ultrastar.Song
+	}{
+		"keep": {false, ultrastar.Song{
+			Title:      "Some Title",
+			Artist:     "Other Artist",
+			Year:       2001,
+			CustomTags: map[string]string{"MYTAG": "some value"},
+		}},
+		"overwrite": {true, ultrastar.Song{
+			Title:      "Other Title",
+			Artist:     "Other Artist",
+			Year:       2001,
+			CustomTags: map[string]string{"MYTAG": "other value"},
+		}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := ultrastar.Song{
+				Title:      "Some Title",
+				CustomTags: map[string]string{"MYTAG": "some value"},
+			}
+			if err := MergeTags(&s, tags, c.overwrite); err != nil {
+				t.Fatalf("MergeTags() caused an unexpected error: %s", err)
+			}
+			if s.Title != c.expected.Title || s.Artist != c.expected.Artist || s.Year != c.expected.Year {
+				t.Errorf("MergeTags() resulted in %q, %q, %d, expected %q, %q, %d", s.Title, s.Artist, s.Year, c.expected.Title, c.expected.Artist, c.expected.Year)
+			}
+			if len(s.CustomTags) != 1 || s.CustomTags["MYTAG"] != c.expected.CustomTags["MYTAG"] {
+				t.Errorf("s.CustomTags = %v, expected %v", s.CustomTags, c.expected.CustomTags)
+			}
+		})
+	}
+
+	t.Run("invalid value", func(t *testing.T) {
+		s := ultrastar.Song{}
+		err := MergeTags(&s, map[string]string{"YEAR": "foo", "TITLE": "Some Title"}, false)
+		if err == nil {
+			t.Errorf("MergeTags() did not cause an error")
+		}
+		if s.Title != "Some Title" {
+			t.Errorf("s.Title = %q, expected %q", s.Title, "Some Title")
+		}
+	})
+}
+
+func TestCustomTags(t *testing.T) {
+	s, err := ParseSong("#TITLE:Some Title\n#MYTAG:some value\n#RESOLUTION:4\n#BPM:12\n: 1 2 0 Some\n")
+	if err != nil {
+		t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+	}
+	if len(s.CustomTags) != 2 {
+		t.Errorf("len(s.CustomTags) = %d, expected 2", len(s.CustomTags))
+	}
+	tags := CustomTags(s)
+	if len(tags) != 1 || tags["MYTAG"] != "some value" {
+		t.Errorf("CustomTags(s) = %v, expected only %q", tags, "MYTAG")
+	}
+}
@@ -3,6 +3,7 @@ package txt
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -15,6 +16,12 @@ func WriteSong(w io.Writer, s ultrastar.Song) error {
 	return NewWriter(w).WriteSong(s)
 }
 
+// WriteAllSongs serializes songs into w.
+// This is a convenience method for [Writer.WriteAllSongs].
+func WriteAllSongs(w io.Writer, songs []ultrastar.Song) error {
+	return NewWriter(w).WriteAllSongs(songs)
+}
+
 // A Writer implements serialization of [ultrastar.Song] serialized to TXT.
 type Writer struct {
 	// FieldSeparator is a character used to separate fields in note line and line breaks.
@@ -31,19 +38,63 @@ type Writer struct {
 	// CommaFloat indicates that floating point values should use a comma as decimal separator.
 	CommaFloat bool
 
-	// TODO: Allow customization the order of tags
+	// BlankTags are the positions at which blank tag lines (lines consisting only of '#') are written.
+	// Each position is the number of tags preceding the blank line.
+	// Positions must be sorted in ascending order.
+	// This is usually set to the [Reader.BlankTags] value of a parsed song.
+	BlankTags []int
+
+	// PadColumns indicates that the numeric fields of notes are right-aligned to a common width.
+	// This makes songs easier to edit by hand.
+	// When writing a song the widths are determined by the notes of all players.
+	// Parsers ignore the additional spaces, so the output is still a valid song.
+	PadColumns bool
+
+	// MusicalBPMHeader is the name of an additional tag that contains the musical BPM of a song.
+	// The musical BPM is s.BPM / 4, which is the same value as the #BPM tag.
+	// Some tools expect the BPM in a separate tag.
+	// If this is empty (the default) no additional tag is written.
+	// A custom tag of the same name is not written.
+	MusicalBPMHeader string
+
+	// Encoding is the value of the #ENCODING tag that is written for each song.
+	// If this is empty (the default) no #ENCODING tag is written.
+	// The writer does not transcode any text.
+	// If the encoding is not UTF-8 the caller must make sure that the text is already encoded accordingly,
+	// for example by using [TransformSong] or by wrapping the underlying writer in a [golang.org/x/text/transform.Writer].
+	Encoding string
 
-	wr  io.Writer      // underlying writer
-	rel ultrastar.Beat // current relative offset
+	// SortBeforeWrite indicates that notes are sorted by their start beat before they are written.
+	// Notes with the same start beat keep their relative order.
+	// Unsorted notes are sorted in a copy, so the notes passed to w are never modified.
+	SortBeforeWrite bool
+
+	// TagOrder controls the order in which the tags of a song are written.
+	// Tags listed in TagOrder are written first, in the order of the list.
+	// Tag names should be given in their canonical form (see [CanonicalTagName]).
+	// All remaining tags are written afterward in the default order:
+	// known tags in a fixed order, followed by custom tags in alphabetical order.
+	// Tags without a value are never written, even if they are listed.
+	TagOrder []string
+
+	wr     io.Writer      // underlying writer
+	rel    ultrastar.Beat // current relative offset
+	tags   int            // number of tags written in the current song
+	widths [3]int         // widths of the start, duration and pitch fields if w.PadColumns is set
 }
 
 // NewWriter creates a new writer for UltraStar songs.
 // The default settings aim to be compatible with most Karaoke games.
 func NewWriter(wr io.Writer) *Writer {
 	w := &Writer{
-		FieldSeparator: ' ',
-		Relative:       false,
-		CommaFloat:     false,
+		FieldSeparator:   ' ',
+		Relative:         false,
+		CommaFloat:       false,
+		PadColumns:       false,
+		MusicalBPMHeader: "",
+		Encoding:         "",
+		SortBeforeWrite:  false,
+		TagOrder:         nil,
 	}
 	w.Reset(wr)
 	return w
@@ -54,13 +105,16 @@ func NewWriter(wr io.Writer) *Writer {
 func (w *Writer) Reset(wr io.Writer) {
 	w.wr = wr
 	w.rel = 0
+	w.tags = 0
+	w.widths = [3]int{}
 }
 
 // allTags are all tag values that have a corresponding field in [ultrastar.Song].
-// The order of this slice determines the order of tags in TXT files.
+// The order of this slice determines the default order of tags in TXT files (see [Writer.TagOrder]).
 var allTags = []string{
 	TagTitle, TagArtist, TagLanguage, TagEdition, TagGenre, TagYear,
 	TagCreator, TagComment, TagMP3, TagCover, TagBackground, TagVideo,
+	TagAudioURL, TagCoverURL, TagBackgroundURL, TagVideoURL, TagVocalsURL, TagInstrumentalURL,
 	TagVideoGap, TagStart, TagEnd, TagPreviewStart, TagMedleyStartBeat,
 	TagMedleyEndBeat, TagCalcMedley, TagBPM, TagGap, TagP1, TagP2,
 }
@@ -68,58 +122,164 @@ var allTags = []string{
 // WriteSong writes the song s to w in the UltraStar txt format.
 // If an error occurs it is returned, otherwise nil is returned.
 func (w *Writer) WriteSong(s ultrastar.Song) error {
-	for _, tag := range allTags {
-		value := getTag(s, tag, w.CommaFloat)
-		if value != "" {
-			if err := w.WriteTag(tag, value); err != nil {
-				return err
-			}
-		}
-	}
-	if w.Relative {
-		if err := w.WriteTag(TagRelative, "YES"); err != nil {
-			return err
-		}
-	}
-	for tag, value := range s.CustomTags {
-		if err := w.WriteTag(tag, value); err != nil {
-			return err
-		}
+	w.rel = 0
+	w.tags = 0
+	w.widths = [3]int{}
+	if w.PadColumns {
+		w.updateWidths(s.NotesP1)
+		w.updateWidths(s.NotesP2)
 	}
-	if s.IsDuet() {
-		if _, err := io.WriteString(w.wr, "P1\n"); err != nil {
+	for _, tag := range w.tagOrder(s) {
+		if err := w.WriteTag(tag, w.tagValue(s, tag)); err != nil {
 			return err
 		}
 	}
-	if err := w.WriteNotes(s.NotesP1); err != nil {
+	if err := w.writeBlankTags(-1); err != nil {
 		return err
 	}
 	if s.IsDuet() {
-		w.rel = 0
-		if _, err := io.WriteString(w.wr, "P2\n"); err != nil {
+		if err := w.WriteVoice(1, s.NotesP1); err != nil {
 			return err
 		}
-		if err := w.WriteNotes(s.NotesP2); err != nil {
+		if err := w.WriteVoice(2, s.NotesP2); err != nil {
 			return err
 		}
+	} else if err := w.WriteNotes(s.NotesP1); err != nil {
+		return err
 	}
 	_, err := io.WriteString(w.wr, "E\n")
 	return err
 }
 
+// tagOrder returns the names of all tags of s with a non-empty value in the order they are written by w.WriteSong.
+// Tags listed in w.TagOrder come first.
+// All other tags follow in the default order.
+func (w *Writer) tagOrder(s ultrastar.Song) []string {
+	defaults := make([]string, 0, len(allTags)+len(s.CustomTags)+3)
+	for _, tag := range allTags {
+		defaults = append(defaults, tag)
+		if tag == TagBPM && w.MusicalBPMHeader != "" {
+			defaults = append(defaults, w.MusicalBPMHeader)
+		}
+	}
+	if w.Encoding != "" {
+		defaults = append(defaults, TagEncoding)
+	}
+	defaults = append(defaults, TagRelative)
+	// Custom tags are sorted to produce a deterministic output.
+	custom := make([]string, 0, len(s.CustomTags))
+	for tag := range s.CustomTags {
+		if !w.isWrittenTag(tag) {
+			custom = append(custom, tag)
+		}
+	}
+	sort.Strings(custom)
+	defaults = append(defaults, custom...)
+
+	order := make([]string, 0, len(defaults))
+	seen := make(map[string]struct{}, len(defaults))
+	for _, tags := range [][]string{w.TagOrder, defaults} {
+		for _, tag := range tags {
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			if w.tagValue(s, tag) != "" {
+				order = append(order, tag)
+			}
+		}
+	}
+	return order
+}
+
+// tagValue returns the value of tag that w.WriteSong writes for s.
+// An empty string indicates that the tag is not written.
+func (w *Writer) tagValue(s ultrastar.Song, tag string) string {
+	switch {
+	case tag == TagEncoding && w.Encoding != "":
+		return w.Encoding
+	case tag == TagRelative:
+		if w.Relative {
+			return "YES"
+		}
+		return ""
+	case w.MusicalBPMHeader != "" && tag == w.MusicalBPMHeader:
+		return getTag(s, TagBPM, w.CommaFloat)
+	case IsKnownTag(tag) && tag == CanonicalTagName(tag):
+		return getTag(s, tag, w.CommaFloat)
+	default:
+		return s.CustomTags[tag]
+	}
+}
+
+// isWrittenTag indicates whether a custom tag with the specified name is already written by w.WriteSong
+// and must not be written again.
+// These are tags with preserved values and tags written because of the configuration of w.
+func (w *Writer) isWrittenTag(tag string) bool {
+	switch tag {
+	case TagBPM, TagMedleyStartBeat, TagMedleyEndBeat:
+		return true
+	case TagEncoding:
+		return w.Encoding != ""
+	default:
+		return w.MusicalBPMHeader != "" && tag == w.MusicalBPMHeader
+	}
+}
+
+// WriteAllSongs writes songs to w, one after another.
+// Each song is terminated by an end tag,
+// so the output can be read using [Reader.ReadAllSongs].
+func (w *Writer) WriteAllSongs(songs []ultrastar.Song) error {
+	for _, s := range songs {
+		if err := w.WriteSong(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WriteTag writes a single tag.
 // Neither the tag nor the value are validated or normalized, they are written as-is.
+// Blank tag lines configured via w.BlankTags are written before the tag as needed.
 func (w *Writer) WriteTag(tag string, value string) error {
+	if err := w.writeBlankTags(w.tags); err != nil {
+		return err
+	}
 	s := fmt.Sprintf("#%s:%s\n", tag, value)
 	_, err := io.WriteString(w.wr, s)
+	w.tags++
 	return err
 }
 
+// writeBlankTags writes a blank tag line for every position in w.BlankTags
+// that is greater than or equal to the number of tags written so far and
+// less than or equal to upTo.
+// If upTo is negative all remaining blank tag lines are written.
+func (w *Writer) writeBlankTags(upTo int) error {
+	for _, pos := range w.BlankTags {
+		if pos < w.tags || (upTo >= 0 && pos > upTo) {
+			continue
+		}
+		if _, err := io.WriteString(w.wr, "#\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WriteNotes writes all notes, line breaks and BPM changes in m in standard UltraStar format.
 //
 // Depending on the value of w.Relative the notes may be written in relative mode.
+// If w.SortBeforeWrite is set, the notes are written in sorted order.
 // A #RELATIVE tag is NOT written automatically in this case.
 func (w *Writer) WriteNotes(ns ultrastar.Notes) error {
+	if w.SortBeforeWrite && !ns.IsSorted() {
+		ns = append(ultrastar.Notes(nil), ns...)
+		sort.Stable(ns)
+	}
+	if w.PadColumns {
+		w.updateWidths(ns)
+	}
 	for _, n := range ns {
 		if err := w.WriteNote(n); err != nil {
 			return err
@@ -128,27 +288,72 @@ func (w *Writer) WriteNotes(ns ultrastar.Notes) error {
 	return nil
 }
 
+// updateWidths increases w.widths so that the fields of all notes in ns fit.
+// The widths are calculated for the values as they will be written by w.
+func (w *Writer) updateWidths(ns ultrastar.Notes) {
+	update := func(i int, v int) {
+		if l := len(strconv.Itoa(v)); l > w.widths[i] {
+			w.widths[i] = l
+		}
+	}
+	var rel ultrastar.Beat
+	for _, n := range ns {
+		start := n.Start
+		if w.Relative {
+			start -= rel
+		}
+		update(0, int(start))
+		if n.Type.IsLineBreak() {
+			if w.Relative {
+				update(1, int(start))
+				rel += start
+			}
+			continue
+		}
+		update(1, int(n.Duration))
+		update(2, int(n.Pitch))
+	}
+}
+
+// WriteVoice writes the notes of a single duet player.
+// The notes are preceded by a player change line (P1 or P2).
+// player must be either 1 or 2, otherwise ErrInvalidPNumber is returned.
+//
+// The relative offset is reset before the notes are written,
+// so notes are written correctly in relative mode.
+func (w *Writer) WriteVoice(player int, ns ultrastar.Notes) error {
+	if player < 1 || player > 2 {
+		return ErrInvalidPNumber
+	}
+	w.rel = 0
+	if _, err := io.WriteString(w.wr, "P"+strconv.Itoa(player)+"\n"); err != nil {
+		return err
+	}
+	return w.WriteNotes(ns)
+}
+
 // WriteNote writes a single note line.
 // Depending on w.Relative the note is adjusted to the current relative offset.
+// A note with an empty text (such as a freestyle note without text) is written with a trailing field separator.
 func (w *Writer) WriteNote(n ultrastar.Note) error {
 	var parts []string
 	if w.Relative {
 		n.Start -= w.rel
 	}
+	// Unknown note types (see Reader.StrictNoteTypes) are written like regular notes.
 	if n.Type.IsLineBreak() {
-		beat := strconv.Itoa(int(n.Start))
 		if w.Relative {
-			parts = []string{string(ultrastar.NoteTypeLineBreak), beat, beat}
+			parts = []string{string(ultrastar.NoteTypeLineBreak), w.formatField(0, int(n.Start)), w.formatField(1, int(n.Start))}
 			w.rel += n.Start
 		} else {
-			parts = []string{string(ultrastar.NoteTypeLineBreak), beat}
+			parts = []string{string(ultrastar.NoteTypeLineBreak), w.formatField(0, int(n.Start))}
 		}
 	} else {
 		parts = []string{
 			string(n.Type),
-			strconv.Itoa(int(n.Start)),
-			strconv.Itoa(int(n.Duration)),
-			strconv.Itoa(int(n.Pitch)),
+			w.formatField(0, int(n.Start)),
+			w.formatField(1, int(n.Duration)),
+			w.formatField(2, int(n.Pitch)),
 			n.Text,
 		}
 	}
@@ -156,3 +361,13 @@ func (w *Writer) WriteNote(n ultrastar.Note) error {
 	_, err := io.WriteString(w.wr, s)
 	return err
 }
+
+// formatField formats v as the i-th numeric field of a note.
+// The value is padded with spaces to w.widths[i].
+func (w *Writer) formatField(i int, v int) string {
+	s := strconv.Itoa(v)
+	if len(s) < w.widths[i] {
+		s = strings.Repeat(" ", w.widths[i]-len(s)) + s
+	}
+	return s
+}
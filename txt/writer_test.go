@@ -2,10 +2,12 @@ package txt
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"codello.dev/ultrastar"
 )
@@ -131,3 +133,408 @@ func TestReadWriteSong(t *testing.T) {
 		t.Errorf("WriteNotes(b, ns) resulted in %q, expected %q", actualStr, expectedStr)
 	}
 }
+
+func TestReadWriteSong_Medley(t *testing.T) {
+	s := ultrastar.Song{
+		BPM:             300,
+		Gap:             1500 * time.Millisecond,
+		MedleyStartBeat: 24,
+		MedleyEndBeat:   128,
+		NotesP1: ultrastar.Notes{
+			{Type: ultrastar.NoteTypeRegular, Start: 24, Duration: 4, Pitch: 3, Text: "Some"},
+		},
+	}
+	b := &strings.Builder{}
+	if err := WriteSong(b, s); err != nil {
+		t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+	}
+	actual, err := ParseSong(b.String())
+	if err != nil {
+		t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+	}
+	if actual.MedleyStartBeat != s.MedleyStartBeat {
+		t.Errorf("s.MedleyStartBeat = %d, expected %d", actual.MedleyStartBeat, s.MedleyStartBeat)
+	}
+	if actual.MedleyEndBeat != s.MedleyEndBeat {
+		t.Errorf("s.MedleyEndBeat = %d, expected %d", actual.MedleyEndBeat, s.MedleyEndBeat)
+	}
+	if actual.Gap != s.Gap {
+		t.Errorf("s.Gap = %s, expected %s", actual.Gap, s.Gap)
+	}
+}
+
+func TestWriter_WriteVoice(t *testing.T) {
+	ns := ultrastar.Notes{
+		{Type: ultrastar.NoteTypeRegular, Start: 2, Duration: 4, Pitch: 8, Text: "some"},
+		{Type: ultrastar.NoteTypeLineBreak, Start: 7, Text: "\n"},
+		{Type: ultrastar.NoteTypeGolden, Start: 8, Duration: 4, Pitch: 8, Text: "body"},
+	}
+	expected := &strings.Builder{}
+	w := NewWriter(expected)
+	_, _ = io.WriteString(expected, "P2\n")
+	for _, n := range ns {
+		_ = w.WriteNote(n)
+	}
+
+	actual := &strings.Builder{}
+	if err := NewWriter(actual).WriteVoice(2, ns); err != nil {
+		t.Errorf("WriteVoice(2, ns) caused an unexpected error: %s", err)
+	}
+	if actual.String() != expected.String() {
+		t.Errorf("WriteVoice(2, ns) resulted in %q, expected %q", actual.String(), expected.String())
+	}
+
+	if err := NewWriter(actual).WriteVoice(3, ns); err != ErrInvalidPNumber {
+		t.Errorf("WriteVoice(3, ns) = %v, expected ErrInvalidPNumber", err)
+	}
+}
+
+func TestReadWriteSong_BPM(t *testing.T) {
+	s, err := ParseSong("#BPM:120\n: 1 2 0 Some\n")
+	if err != nil {
+		t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+	}
+	if s.BPM != 480 {
+		t.Errorf("s.BPM = %f, expected %f", s.BPM, ultrastar.BPM(480))
+	}
+	b := &strings.Builder{}
+	if err = WriteSong(b, s); err != nil {
+		t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+	}
+	if !strings.Contains(b.String(), "#BPM:120\n") {
+		t.Errorf("WriteSong(b, s) resulted in %q, expected it to contain %q", b.String(), "#BPM:120\n")
+	}
+}
+
+func TestReadWriteSong_PreserveBPMString(t *testing.T) {
+	r := NewReader(strings.NewReader("#BPM:199,96\n: 1 2 0 Some\n"))
+	r.PreserveBPMString = true
+	s, err := r.ReadSong()
+	if err != nil {
+		t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+	}
+	b := &strings.Builder{}
+	if err = WriteSong(b, s); err != nil {
+		t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+	}
+	expected := "#BPM:199,96\n: 1 2 0 Some\nE\n"
+	if b.String() != expected {
+		t.Errorf("WriteSong(b, s) resulted in %q, expected %q", b.String(), expected)
+	}
+
+	s.BPM = 480
+	b.Reset()
+	if err = WriteSong(b, s); err != nil {
+		t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+	}
+	expected = "#BPM:120\n: 1 2 0 Some\nE\n"
+	if b.String() != expected {
+		t.Errorf("WriteSong(b, s) resulted in %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestWriter_PadColumns(t *testing.T) {
+	s := ultrastar.Song{
+		NotesP1: ultrastar.Notes{
+			{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Pitch: 0, Text: "Some"},
+			{Type: ultrastar.NoteTypeLineBreak, Start: 4, Text: "\n"},
+			{Type: ultrastar.NoteTypeGolden, Start: 120, Duration: 12, Pitch: -3, Text: "body"},
+		},
+		NotesP2: ultrastar.Notes{
+			{Type: ultrastar.NoteTypeRegular, Start: 5, Duration: 1, Pitch: 12, Text: "once"},
+		},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.PadColumns = true
+	if err := w.WriteSong(s); err != nil {
+		t.Fatalf("WriteSong(s) caused an unexpected error: %s", err)
+	}
+	expected := `P1
+:   1  2  0 Some
+-   4
+* 120 12 -3 body
+P2
+:   5  1 12 once
+E
+`
+	if b.String() != expected {
+		t.Errorf("WriteSong(s) resulted in %q, expected %q", b.String(), expected)
+	}
+	actual, err := ParseSong(b.String())
+	if err != nil {
+		t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+	}
+	if !actual.NotesP1.Equal(s.NotesP1) || !actual.NotesP2.Equal(s.NotesP2) {
+		t.Errorf("ParseSong() = %v, expected %v", actual, s)
+	}
+}
+
+func TestWriter_SortBeforeWrite(t *testing.T) {
+	s := ultrastar.Song{
+		NotesP1: ultrastar.Notes{
+			{Type: ultrastar.NoteTypeRegular, Start: 5, Duration: 1, Pitch: 0, Text: "body"},
+			{Type: ultrastar.NoteTypeLineBreak, Start: 4, Text: "\n"},
+			{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Pitch: 0, Text: "Some"},
+		},
+	}
+	original := append(ultrastar.Notes(nil), s.NotesP1...)
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.SortBeforeWrite = true
+	if err := w.WriteSong(s); err != nil {
+		t.Fatalf("WriteSong(s) caused an unexpected error: %s", err)
+	}
+	expected := `: 1 2 0 Some
+- 4
+: 5 1 0 body
+E
+`
+	if b.String() != expected {
+		t.Errorf("WriteSong(s) resulted in %q, expected %q", b.String(), expected)
+	}
+	if !s.NotesP1.Equal(original) {
+		t.Errorf("WriteSong(s) modified s.NotesP1 to %v, expected %v", s.NotesP1, original)
+	}
+}
+
+func TestWriter_TagOrder(t *testing.T) {
+	s := ultrastar.Song{
+		Title:      "Some Title",
+		Artist:     "Some Artist",
+		BPM:        48,
+		CustomTags: map[string]string{"ZTAG": "z", "ATAG": "a", "RESOLUTION": "4"},
+		NotesP1: ultrastar.Notes{
+			{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Pitch: 0, Text: "Some"},
+		},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.TagOrder = []string{TagBPM, "ZTAG", TagArtist, TagGenre}
+	if err := w.WriteSong(s); err != nil {
+		t.Fatalf("WriteSong(s) caused an unexpected error: %s", err)
+	}
+	expected := `#BPM:12
+#ZTAG:z
+#ARTIST:Some Artist
+#TITLE:Some Title
+#ATAG:a
+#RESOLUTION:4
+: 1 2 0 Some
+E
+`
+	if b.String() != expected {
+		t.Errorf("WriteSong(s) resulted in %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestWriter_MusicalBPMHeader(t *testing.T) {
+	s := ultrastar.Song{
+		BPM:        4 * 123.5,
+		CustomTags: map[string]string{"MUSICALBPM": "1"},
+		NotesP1:    ultrastar.Notes{{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Text: "Some"}},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.MusicalBPMHeader = "MUSICALBPM"
+	if err := w.WriteSong(s); err != nil {
+		t.Fatalf("WriteSong(s) caused an unexpected error: %s", err)
+	}
+	expected := "#BPM:123.5\n#MUSICALBPM:123.5\n: 1 2 0 Some\nE\n"
+	if b.String() != expected {
+		t.Errorf("WriteSong(s) resulted in %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestReadWriteSong_PreserveMedleyStrings(t *testing.T) {
+	song := "#MEDLEYSTARTBEAT:007\n#MEDLEYENDBEAT:0120\n: 1 2 0 Some\nE\n"
+	r := NewReader(strings.NewReader(song))
+	r.PreserveMedleyStrings = true
+	s, err := r.ReadSong()
+	if err != nil {
+		t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+	}
+	if s.MedleyStartBeat != 7 {
+		t.Errorf("s.MedleyStartBeat = %d, expected 7", s.MedleyStartBeat)
+	}
+	b := &strings.Builder{}
+	if err = WriteSong(b, s); err != nil {
+		t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+	}
+	if b.String() != song {
+		t.Errorf("WriteSong(b, s) resulted in %q, expected %q", b.String(), song)
+	}
+
+	s.MedleyEndBeat = 100
+	b.Reset()
+	if err = WriteSong(b, s); err != nil {
+		t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+	}
+	expected := "#MEDLEYSTARTBEAT:007\n#MEDLEYENDBEAT:100\n: 1 2 0 Some\nE\n"
+	if b.String() != expected {
+		t.Errorf("WriteSong(b, s) resulted in %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestWriter_Encoding(t *testing.T) {
+	s := ultrastar.Song{
+		Title:      "Perfekte Welle",
+		CustomTags: map[string]string{TagEncoding: "UTF8"},
+		NotesP1:    ultrastar.Notes{{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Text: "Some"}},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.Encoding = "CP1252"
+	if err := w.WriteSong(s); err != nil {
+		t.Fatalf("WriteSong(s) caused an unexpected error: %s", err)
+	}
+	expected := "#TITLE:Perfekte Welle\n#ENCODING:CP1252\n: 1 2 0 Some\nE\n"
+	if b.String() != expected {
+		t.Errorf("WriteSong(s) resulted in %q, expected %q", b.String(), expected)
+	}
+}
+
+func TestWriter_CommaFloat(t *testing.T) {
+	s := ultrastar.Song{
+		BPM:          4 * 123.45,
+		Gap:          1500*time.Millisecond + 500*time.Microsecond,
+		VideoGap:     2500 * time.Millisecond,
+		Start:        3500 * time.Millisecond,
+		PreviewStart: 4500 * time.Millisecond,
+		NotesP1:      ultrastar.Notes{{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Text: "Some"}},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.CommaFloat = true
+	if err := w.WriteSong(s); err != nil {
+		t.Fatalf("WriteSong(s) caused an unexpected error: %s", err)
+	}
+	for _, tag := range []string{"#BPM:123,45\n", "#GAP:1500,5\n", "#VIDEOGAP:2,5\n", "#START:3,5\n", "#PREVIEWSTART:4,5\n"} {
+		if !strings.Contains(b.String(), tag) {
+			t.Errorf("WriteSong(s) resulted in %q, expected it to contain %q", b.String(), tag)
+		}
+	}
+}
+
+func TestWriteSong_CustomTags(t *testing.T) {
+	s := ultrastar.Song{
+		CustomTags: map[string]string{"ZEBRA": "1", "APPLE": "2", "MANGO": "3", "KIWI": "4", "BANANA": "5"},
+		NotesP1:    ultrastar.Notes{{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Text: "Some"}},
+	}
+	expected := "#APPLE:2\n#BANANA:5\n#KIWI:4\n#MANGO:3\n#ZEBRA:1\n: 1 2 0 Some\nE\n"
+	for i := 0; i < 10; i++ {
+		b := &strings.Builder{}
+		if err := WriteSong(b, s); err != nil {
+			t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+		}
+		if b.String() != expected {
+			t.Fatalf("WriteSong(b, s) resulted in %q, expected %q", b.String(), expected)
+		}
+	}
+}
+
+func TestWriteAllSongs(t *testing.T) {
+	songs := []ultrastar.Song{
+		{Title: "First", BPM: 48, NotesP1: ultrastar.Notes{
+			{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Text: "Some"},
+			{Type: ultrastar.NoteTypeLineBreak, Start: 4, Text: "\n"},
+			{Type: ultrastar.NoteTypeRegular, Start: 5, Duration: 2, Text: "body"},
+		}},
+		{Title: "Second", BPM: 96, NotesP1: ultrastar.Notes{
+			{Type: ultrastar.NoteTypeRegular, Start: 1, Duration: 2, Text: "once"},
+		}},
+	}
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.Relative = true
+	if err := w.WriteAllSongs(songs); err != nil {
+		t.Fatalf("WriteAllSongs(songs) caused an unexpected error: %s", err)
+	}
+	actual, err := ReadAllSongs(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("ReadAllSongs() caused an unexpected error: %s", err)
+	}
+	if len(actual) != len(songs) {
+		t.Fatalf("len(ReadAllSongs()) = %d, expected %d", len(actual), len(songs))
+	}
+	for i := range songs {
+		if actual[i].Title != songs[i].Title || actual[i].BPM != songs[i].BPM || !actual[i].NotesP1.Equal(songs[i].NotesP1) {
+			t.Errorf("ReadAllSongs()[%d] = %v, expected %v", i, actual[i], songs[i])
+		}
+	}
+}
+
+func TestReadWriteSong_BlankTags(t *testing.T) {
+	song := `#TITLE:All Star
+#
+#ARTIST:Smash Mouth
+#BPM:312
+#
+: 0 6 6 Some
+E
+`
+	r := NewReader(strings.NewReader(song))
+	r.PreserveBlankTags = true
+	s, err := r.ReadSong()
+	if err != nil {
+		t.Fatalf("ReadSong() caused an unexpected error: %s", err)
+	}
+	if len(s.CustomTags) != 0 {
+		t.Errorf("len(s.CustomTags) = %d, expected 0", len(s.CustomTags))
+	}
+
+	b := &strings.Builder{}
+	w := NewWriter(b)
+	w.BlankTags = r.BlankTags
+	if err = w.WriteSong(s); err != nil {
+		t.Fatalf("WriteSong(s) caused an unexpected error: %s", err)
+	}
+	if b.String() != song {
+		t.Errorf("WriteSong(s) resulted in %q, expected %q", b.String(), song)
+	}
+}
+
+func TestReadWriteSong_URL(t *testing.T) {
+	s, err := ParseSong("#TITLE:All Star\n#AUDIOURL:https://example.com/all-star?a=b\n#BPM:12\n: 1 2 0 Some\n")
+	if err != nil {
+		t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+	}
+	if s.AudioURL == nil || s.AudioURL.Host != "example.com" {
+		t.Fatalf("s.AudioURL = %v, expected a URL with host %q", s.AudioURL, "example.com")
+	}
+	if len(s.CustomTags) != 0 {
+		t.Errorf("len(s.CustomTags) = %d, expected 0", len(s.CustomTags))
+	}
+	b := &strings.Builder{}
+	if err = WriteSong(b, s); err != nil {
+		t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+	}
+	if !strings.Contains(b.String(), "#AUDIOURL:https://example.com/all-star?a=b\n") {
+		t.Errorf("WriteSong(b, s) resulted in %q, expected it to contain the #AUDIOURL tag", b.String())
+	}
+}
+
+func TestReadWriteSong_EmptyFreestyleText(t *testing.T) {
+	song := "#BPM:12\n: 1 2 0 Some\nF 3 2 0 \nF 5 2 0\n"
+	s, err := ParseSong(song)
+	if err != nil {
+		t.Fatalf("ParseSong() caused an unexpected error: %s", err)
+	}
+	if len(s.NotesP1) != 3 || s.NotesP1[1].Text != "" || s.NotesP1[2].Text != "" {
+		t.Fatalf("s.NotesP1 = %v, expected two freestyle notes with empty text", s.NotesP1)
+	}
+	b := &strings.Builder{}
+	if err = WriteSong(b, s); err != nil {
+		t.Fatalf("WriteSong(b, s) caused an unexpected error: %s", err)
+	}
+	expected := "#BPM:12\n: 1 2 0 Some\nF 3 2 0 \nF 5 2 0 \nE\n"
+	if b.String() != expected {
+		t.Errorf("WriteSong(b, s) resulted in %q, expected %q", b.String(), expected)
+	}
+
+	r := NewReader(strings.NewReader(song))
+	r.AllowEmptyFreestyleText = false
+	if _, err = r.ReadSong(); !errors.Is(err, ErrInvalidNote) {
+		t.Errorf("ReadSong() did not cause ErrInvalidNote, but: %v", err)
+	}
+}
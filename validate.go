@@ -0,0 +1,112 @@
+package ultrastar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// These known errors might be returned by [Song.Validate].
+var (
+	// ErrUnsortedNotes denotes that a note starts before its predecessor.
+	ErrUnsortedNotes = errors.New("unsorted notes")
+	// ErrUnknownNoteType denotes that a note has a type that is not valid as determined by [NoteType.IsValid].
+	ErrUnknownNoteType = errors.New("unknown note type")
+	// ErrLineBreakText denotes that a line break has a text other than "\n".
+	ErrLineBreakText = errors.New("line break with text")
+	// ErrEmptyNamedPlayer denotes that a player has a name but no notes.
+	ErrEmptyNamedPlayer = errors.New("named player without notes")
+)
+
+// A ValidationError is returned by [Song.Validate].
+// It contains all problems found in a song.
+// Use [errors.Is] to check for specific problems.
+type ValidationError struct {
+	// Errors contains the individual problems in the order they were found.
+	Errors []error
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	errs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err.Error()
+	}
+	return strings.Join(errs, "\n")
+}
+
+// Unwrap returns the individual problems of e.
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+// Is reports whether any of the problems in e matches target.
+func (e *ValidationError) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate performs a series of sanity checks on s.
+// If any problems are found, a *ValidationError is returned that contains all of them.
+// The following problems are detected:
+//
+//   - An invalid BPM (ErrInvalidBPM).
+//   - Notes that start before the previous note of the same player (ErrUnsortedNotes).
+//   - Notes that start before the previous note of the same player has ended (ErrOverlappingNotes).
+//   - Notes with a negative duration (ErrNegativeDuration).
+//   - Notes with an unknown note type (ErrUnknownNoteType).
+//   - Line breaks with a text other than "\n" (ErrLineBreakText).
+//   - Players without notes that have a name (ErrEmptyNamedPlayer).
+//
+// Validate does not modify s. Some problems can be fixed via [Song.Normalize].
+func (s *Song) Validate() error {
+	var errs []error
+	if !s.BPM.IsValid() {
+		errs = append(errs, ErrInvalidBPM)
+	}
+	errs = appendNotesErrors(errs, 1, s.NotesP1)
+	if s.IsDuet() {
+		errs = appendNotesErrors(errs, 2, s.NotesP2)
+	}
+	if len(s.NotesP1) == 0 && s.DuetSinger1 != "" {
+		errs = append(errs, fmt.Errorf("player 1: %w", ErrEmptyNamedPlayer))
+	}
+	if len(s.NotesP2) == 0 && s.DuetSinger2 != "" {
+		errs = append(errs, fmt.Errorf("player 2: %w", ErrEmptyNamedPlayer))
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{errs}
+}
+
+// appendNotesErrors appends all problems in the notes of the specified player to errs.
+func appendNotesErrors(errs []error, player int, ns Notes) []error {
+	prev := -1
+	var end Beat
+	for i, n := range ns {
+		if !n.Type.IsValid() {
+			errs = append(errs, fmt.Errorf("player %d, note %d: %w", player, i, ErrUnknownNoteType))
+		}
+		if n.Duration < 0 {
+			errs = append(errs, fmt.Errorf("player %d, note %d: %w", player, i, ErrNegativeDuration))
+		}
+		if n.Type == NoteTypeLineBreak && n.Text != "\n" {
+			errs = append(errs, fmt.Errorf("player %d, note %d: %w", player, i, ErrLineBreakText))
+		}
+		if i > 0 && n.Start < ns[i-1].Start {
+			errs = append(errs, fmt.Errorf("player %d, note %d: %w", player, i, ErrUnsortedNotes))
+		} else if n.Type != NoteTypeLineBreak && prev >= 0 && n.Start < end {
+			errs = append(errs, fmt.Errorf("player %d, notes %d and %d: %w", player, prev, i, ErrOverlappingNotes))
+		}
+		if n.Type != NoteTypeLineBreak {
+			prev = i
+			end = n.End()
+		}
+	}
+	return errs
+}
@@ -0,0 +1,55 @@
+package ultrastar
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSong_Validate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		s := NewTestSong(10)
+		if err := s.Validate(); err != nil {
+			t.Errorf("s.Validate() = %v, expected nil", err)
+		}
+	})
+
+	cases := map[string]struct {
+		modify   func(s *Song)
+		expected error
+	}{
+		"invalid BPM": {func(s *Song) { s.BPM = 0 }, ErrInvalidBPM},
+		"overlapping notes": {func(s *Song) {
+			s.NotesP1[1].Start = s.NotesP1[0].Start + 1
+		}, ErrOverlappingNotes},
+		"unsorted notes": {func(s *Song) {
+			s.NotesP1[0], s.NotesP1[1] = s.NotesP1[1], s.NotesP1[0]
+		}, ErrUnsortedNotes},
+		"negative duration": {func(s *Song) {
+			s.NotesP1[0].Duration = -1
+		}, ErrNegativeDuration},
+		"unknown note type": {func(s *Song) {
+			s.NotesP1[0].Type = 'Q'
+		}, ErrUnknownNoteType},
+		"line break text": {func(s *Song) {
+			s.NotesP1[1] = Note{Type: NoteTypeLineBreak, Start: s.NotesP1[1].Start, Text: "foo"}
+		}, ErrLineBreakText},
+		"empty named player": {func(s *Song) {
+			s.NotesP2 = Notes{}
+			s.DuetSinger2 = "Bob"
+		}, ErrEmptyNamedPlayer},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := NewTestSong(4)
+			c.modify(s)
+			err := s.Validate()
+			if !errors.Is(err, c.expected) {
+				t.Errorf("s.Validate() = %v, expected %v", err, c.expected)
+			}
+			var vErr *ValidationError
+			if !errors.As(err, &vErr) || len(vErr.Errors) != 1 {
+				t.Errorf("s.Validate() = %v, expected exactly one error", err)
+			}
+		})
+	}
+}